@@ -3,6 +3,7 @@ module github.com/go-vela/sdk-go
 go 1.26.3
 
 require (
+	github.com/adhocore/gronx v1.20.0
 	github.com/coreos/go-semver v0.3.1
 	github.com/gin-gonic/gin v1.12.0
 	github.com/go-vela/server v0.28.8
@@ -315,6 +315,12 @@ func (svc *AdminOIDCService) RotateOIDCKeys(ctx context.Context) (*string, *Resp
 
 	// send request using client
 	resp, err := svc.client.Call(ctx, "POST", url, nil, v)
+	if err != nil {
+		return v, resp, err
+	}
+
+	// let token verifiers for the client know the keys changed
+	svc.client.oidcRotations.Add(1)
 
 	return v, resp, err
 }
@@ -11,6 +11,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/sirupsen/logrus"
+
 	api "github.com/go-vela/server/api/types"
 	"github.com/go-vela/server/constants"
 )
@@ -33,19 +35,26 @@ type AuthenticationService struct {
 	token               *string
 	personalAccessToken *string
 	accessToken         *string
+	accessTokenAt       time.Time
 	refreshToken        *string
 	scmToken            *string
 	authType            AuthenticationType
 	scmTokenExp         *int64
+	scmTokenAt          time.Time
 	buildRepo           *string
 	buildNumber         *int64
 	scmAuthMu           sync.RWMutex
+	tokenMu             sync.RWMutex
+	refresher           *tokenRefresher
+	store               CredentialStore
+	storeProfile        string
+	expirySkew          time.Duration
 }
 
 // SetTokenAuth sets the authentication type as a plain token.
 func (svc *AuthenticationService) SetTokenAuth(token string) {
 	svc.token = new(token)
-	svc.authType = AuthenticationToken
+	svc.setAuthType(AuthenticationToken)
 }
 
 // SetBuildTokenAuth sets the authentication type and the two tokens used.
@@ -55,6 +64,7 @@ func (svc *AuthenticationService) SetBuildTokenAuth(buildTkn, scmTkn string, scm
 
 	svc.token = new(buildTkn)
 	svc.scmToken = new(scmTkn)
+	svc.scmTokenAt = time.Now()
 	svc.buildRepo = new(buildRepo)
 	svc.buildNumber = new(buildNumber)
 
@@ -63,49 +73,93 @@ func (svc *AuthenticationService) SetBuildTokenAuth(buildTkn, scmTkn string, scm
 		svc.scmTokenExp = &scmTokenExp
 	}
 
-	svc.authType = BuildToken
+	svc.setAuthType(BuildToken)
 }
 
 // SetPersonalAccessTokenAuth sets the authentication type as personal access token.
 func (svc *AuthenticationService) SetPersonalAccessTokenAuth(token string) {
 	svc.personalAccessToken = new(token)
-	svc.authType = PersonalAccessToken
+	svc.setAuthType(PersonalAccessToken)
 }
 
 // SetAccessAndRefreshAuth sets the authentication type as oauth token pair.
 func (svc *AuthenticationService) SetAccessAndRefreshAuth(access, refresh string) {
+	svc.tokenMu.Lock()
+	defer svc.tokenMu.Unlock()
+
 	svc.accessToken = new(access)
+	svc.accessTokenAt = time.Time{}
 	svc.refreshToken = new(refresh)
 	svc.authType = AccessAndRefreshToken
 }
 
+// SetCredentialStore sets the store the access and refresh tokens
+// are saved to for the profile whenever they are exchanged or refreshed.
+func (svc *AuthenticationService) SetCredentialStore(store CredentialStore, profile string) {
+	svc.tokenMu.Lock()
+	defer svc.tokenMu.Unlock()
+
+	svc.store = store
+	svc.storeProfile = profile
+}
+
+// SetTokenExpirySkew sets the time before the expiration of a token
+// after which the client treats it as expired, allowing for clock skew
+// and the time to send a request. The default skew is 10s.
+func (svc *AuthenticationService) SetTokenExpirySkew(skew time.Duration) {
+	svc.tokenMu.Lock()
+	defer svc.tokenMu.Unlock()
+
+	svc.expirySkew = skew
+}
+
 // HasAuth checks if the authentication type is set.
 func (svc *AuthenticationService) HasAuth() bool {
-	return svc.authType > 0
+	return svc.getAuthType() > 0
 }
 
 // HasTokenAuth checks if the authentication type is a plain token.
 func (svc *AuthenticationService) HasTokenAuth() bool {
-	return svc.authType == AuthenticationToken
+	return svc.getAuthType() == AuthenticationToken
 }
 
 // HasBuildTokenAuth checks if the authentication type is a build and scm token.
 func (svc *AuthenticationService) HasBuildTokenAuth() bool {
-	return svc.authType == BuildToken
+	return svc.getAuthType() == BuildToken
 }
 
 // HasPersonalAccessTokenAuth checks if the authentication type is a personal access token.
 func (svc *AuthenticationService) HasPersonalAccessTokenAuth() bool {
-	return svc.authType == PersonalAccessToken
+	return svc.getAuthType() == PersonalAccessToken
 }
 
 // HasAccessAndRefreshAuth checks if the authentication type is oauth token pair.
 func (svc *AuthenticationService) HasAccessAndRefreshAuth() bool {
-	return svc.authType == AccessAndRefreshToken
+	return svc.getAuthType() == AccessAndRefreshToken
+}
+
+// setAuthType sets the authentication type. The type is read
+// by the background refresher, so it is guarded by tokenMu.
+func (svc *AuthenticationService) setAuthType(t AuthenticationType) {
+	svc.tokenMu.Lock()
+	defer svc.tokenMu.Unlock()
+
+	svc.authType = t
+}
+
+// getAuthType returns the authentication type.
+func (svc *AuthenticationService) getAuthType() AuthenticationType {
+	svc.tokenMu.RLock()
+	defer svc.tokenMu.RUnlock()
+
+	return svc.authType
 }
 
 // getAccessToken returns the active access token value or an error.
 func (svc *AuthenticationService) getAccessToken() (string, error) {
+	svc.tokenMu.RLock()
+	defer svc.tokenMu.RUnlock()
+
 	if svc.accessToken == nil || len(*svc.accessToken) == 0 {
 		return "", fmt.Errorf("access token has no value - please log in again with 'vela login'")
 	}
@@ -115,6 +169,9 @@ func (svc *AuthenticationService) getAccessToken() (string, error) {
 
 // getRefreshToken returns the active refresh token value or an error.
 func (svc *AuthenticationService) getRefreshToken() (string, error) {
+	svc.tokenMu.RLock()
+	defer svc.tokenMu.RUnlock()
+
 	if svc.refreshToken == nil || len(*svc.refreshToken) == 0 {
 		return "", fmt.Errorf("refresh token has no value - please log in again with 'vela login'")
 	}
@@ -135,7 +192,21 @@ func (svc *AuthenticationService) IsTokenAuthExpired() (bool, error) {
 	}
 
 	// check auth token expiration
-	return IsTokenExpired(*svc.token), nil
+	return svc.isTokenExpired(*svc.token), nil
+}
+
+// isTokenExpired returns whether the token is
+// expired given the skew set for the client.
+func (svc *AuthenticationService) isTokenExpired(token string) bool {
+	svc.tokenMu.RLock()
+	skew := svc.expirySkew
+	svc.tokenMu.RUnlock()
+
+	if skew <= 0 {
+		skew = defaultTokenExpirySkew
+	}
+
+	return isTokenExpired(token, skew)
 }
 
 // IsSCMTokenExpired checks if the SCM token has expired.
@@ -220,7 +291,12 @@ func (svc *AuthenticationService) RefreshAccessToken(ctx context.Context, refres
 	}
 
 	// set the received access token
+	svc.tokenMu.Lock()
 	svc.accessToken = v.Token
+	svc.accessTokenAt = time.Now()
+	svc.tokenMu.Unlock()
+
+	svc.saveCredentials()
 
 	return resp, err
 }
@@ -297,12 +373,46 @@ func (svc *AuthenticationService) ExchangeTokens(ctx context.Context, opt *OAuth
 	at := v.GetToken()
 
 	// set the received tokens
+	svc.tokenMu.Lock()
 	svc.accessToken = &at
+	svc.accessTokenAt = time.Now()
 	svc.refreshToken = &rt
+	svc.tokenMu.Unlock()
+
+	svc.saveCredentials()
 
 	return at, rt, resp, err
 }
 
+// saveCredentials saves the access and refresh tokens to the
+// credential store, if one is set. Failing to save does not fail
+// the request the tokens were received for, so it is only logged.
+func (svc *AuthenticationService) saveCredentials() {
+	svc.tokenMu.RLock()
+	store, profile := svc.store, svc.storeProfile
+	svc.tokenMu.RUnlock()
+
+	if store == nil {
+		return
+	}
+
+	// keep the rest of the stored credentials for the profile
+	creds, err := store.Load(profile)
+	if err != nil {
+		creds = new(Credentials)
+	}
+
+	creds.Address = svc.client.baseURL.String()
+
+	creds.AccessToken, _ = svc.getAccessToken()
+	creds.RefreshToken, _ = svc.getRefreshToken()
+
+	err = store.Save(profile, creds)
+	if err != nil {
+		logrus.Warnf("unable to save credentials: %v", err)
+	}
+}
+
 // extractRefreshToken is a helper function to extract
 // the refresh token from the supplied cookie slice.
 func extractRefreshToken(cookies []*http.Cookie) string {
@@ -358,6 +468,12 @@ func (svc *AuthenticationService) refreshInstallTokenIfNeeded(ctx context.Contex
 		return nil
 	}
 
+	return svc.refreshBuildInstallToken(ctx)
+}
+
+// refreshBuildInstallToken refreshes the SCM token for the build
+// the client authenticates as. The caller must hold scmAuthMu.
+func (svc *AuthenticationService) refreshBuildInstallToken(ctx context.Context) error {
 	if svc.buildRepo == nil || svc.buildNumber == nil {
 		return fmt.Errorf("build token authentication details are incomplete")
 	}
@@ -412,6 +528,7 @@ func (svc *AuthenticationService) refreshInstallToken(ctx context.Context, org,
 	// set the received access token
 	svc.scmToken = v.Token
 	svc.scmTokenExp = v.Expiration
+	svc.scmTokenAt = time.Now()
 
 	return resp, err
 }
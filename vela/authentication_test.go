@@ -114,6 +114,24 @@ func TestVela_Authentication_IsTokenAuthExpired_ExpiredAuthToken(t *testing.T) {
 	}
 }
 
+func TestVela_Authentication_IsTokenAuthExpired_Skew(t *testing.T) {
+	// setup types
+	c, _ := NewClient("http://localhost:8080", "", nil)
+
+	// run test
+	c.Authentication.SetTokenAuth(TestTokenGood)
+	c.Authentication.SetTokenExpirySkew(5 * time.Minute)
+
+	expired, err := c.Authentication.IsTokenAuthExpired()
+	if err != nil {
+		t.Errorf("IsTokenAuthExpired returned err: %v", err)
+	}
+
+	if !expired {
+		t.Error("IsTokenAuthExpired did not return expired for token expiring within the skew")
+	}
+}
+
 func TestVela_Authentication_IsTokenAuthExpired_InvalidAuthToken(t *testing.T) {
 	// setup types
 	c, _ := NewClient("http://localhost:8080", "", nil)
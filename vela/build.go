@@ -136,7 +136,15 @@ func (svc *BuildService) Update(ctx context.Context, b *api.Build) (*api.Build,
 }
 
 // Remove deletes the provided build.
+//
+// Deprecated: use Client.ForBuild(org, repo, build).Remove, which takes the
+// build number as an int64.
 func (svc *BuildService) Remove(ctx context.Context, org, repo string, build int) (*string, *Response, error) {
+	return svc.remove(ctx, org, repo, int64(build))
+}
+
+// remove deletes the provided build.
+func (svc *BuildService) remove(ctx context.Context, org, repo string, build int64) (*string, *Response, error) {
 	// set the API endpoint path we send the request to
 	u := fmt.Sprintf("/api/v1/repos/%s/%s/builds/%d", org, repo, build)
 
@@ -220,7 +228,15 @@ func (svc *BuildService) GetIDRequestToken(ctx context.Context, org, repo string
 }
 
 // GetIDToken returns an ID token corresponding to the request token during a build.
+//
+// Deprecated: use Client.ForBuild(org, repo, build).IDToken, which takes the
+// build number as an int64.
 func (svc *BuildService) GetIDToken(ctx context.Context, org, repo string, build int, opt *IDTokenOptions) (*api.Token, *Response, error) {
+	return svc.getIDToken(ctx, org, repo, int64(build), opt)
+}
+
+// getIDToken returns an ID token corresponding to the request token during a build.
+func (svc *BuildService) getIDToken(ctx context.Context, org, repo string, build int64, opt *IDTokenOptions) (*api.Token, *Response, error) {
 	// set the API endpoint path we send the request to
 	u := fmt.Sprintf("/api/v1/repos/%s/%s/builds/%d/id_token", org, repo, build)
 
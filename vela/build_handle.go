@@ -0,0 +1,304 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	api "github.com/go-vela/server/api/types"
+)
+
+// BuildHandle provides the services for a single build, filling in
+// the org, repo and build number for each request. Build numbers are
+// always int64 and step and service numbers are always int32.
+type BuildHandle struct {
+	client *Client
+	org    string
+	repo   string
+	build  int64
+}
+
+// ForBuild returns the services scoped to the build.
+func (c *Client) ForBuild(org, repo string, build int64) *BuildHandle {
+	return &BuildHandle{client: c, org: org, repo: repo, build: build}
+}
+
+// Build returns the services scoped to the build for the repository.
+func (s *RepoScope) Build(build int64) *BuildHandle {
+	return s.client.ForBuild(s.org, s.repo, build)
+}
+
+// Org returns the org for the build.
+func (b *BuildHandle) Org() string {
+	return b.org
+}
+
+// Repo returns the name of the repository for the build.
+func (b *BuildHandle) Repo() string {
+	return b.repo
+}
+
+// Number returns the number of the build.
+func (b *BuildHandle) Number() int64 {
+	return b.build
+}
+
+// Get returns the build.
+func (b *BuildHandle) Get(ctx context.Context) (*api.Build, *Response, error) {
+	return b.client.Build.Get(ctx, b.org, b.repo, b.build)
+}
+
+// Remove deletes the build.
+func (b *BuildHandle) Remove(ctx context.Context) (*string, *Response, error) {
+	return b.client.Build.remove(ctx, b.org, b.repo, b.build)
+}
+
+// Restart restarts the build.
+func (b *BuildHandle) Restart(ctx context.Context) (*api.Build, *Response, error) {
+	return b.client.Build.Restart(ctx, b.org, b.repo, b.build)
+}
+
+// Cancel cancels the build.
+func (b *BuildHandle) Cancel(ctx context.Context) (*api.Build, *Response, error) {
+	return b.client.Build.Cancel(ctx, b.org, b.repo, b.build)
+}
+
+// Approve approves the build.
+func (b *BuildHandle) Approve(ctx context.Context) (*Response, error) {
+	return b.client.Build.Approve(ctx, b.org, b.repo, b.build)
+}
+
+// Executable returns the executable for the build.
+func (b *BuildHandle) Executable(ctx context.Context) (*api.BuildExecutable, *Response, error) {
+	return b.client.Build.GetBuildExecutable(ctx, b.org, b.repo, b.build)
+}
+
+// Token returns an auth token for updating the resources for the build.
+func (b *BuildHandle) Token(ctx context.Context) (*api.Token, *Response, error) {
+	return b.client.Build.GetBuildToken(ctx, b.org, b.repo, b.build)
+}
+
+// IDRequestToken returns an id request token for integrating with build OIDC.
+func (b *BuildHandle) IDRequestToken(ctx context.Context, opt *RequestTokenOptions) (*api.Token, *Response, error) {
+	return b.client.Build.GetIDRequestToken(ctx, b.org, b.repo, b.build, opt)
+}
+
+// IDToken returns an ID token corresponding to the request token for the build.
+func (b *BuildHandle) IDToken(ctx context.Context, opt *IDTokenOptions) (*api.Token, *Response, error) {
+	return b.client.Build.getIDToken(ctx, b.org, b.repo, b.build, opt)
+}
+
+// IDTokenProvider returns a provider caching the ID tokens for the build.
+func (b *BuildHandle) IDTokenProvider(opt *IDTokenProviderOptions) (*IDTokenProvider, error) {
+	return NewIDTokenProvider(b.client, b.org, b.repo, b.build, opt)
+}
+
+// InstallToken returns an SCM install token for the build.
+func (b *BuildHandle) InstallToken(ctx context.Context, tokenRequest *api.TokenRequest) (*api.Token, *Response, error) {
+	return b.client.Build.PostInstallToken(ctx, b.org, b.repo, b.build, tokenRequest)
+}
+
+// UploadURL returns a presigned URL for uploading the object to the storage for the build.
+func (b *BuildHandle) UploadURL(ctx context.Context, objName string) (*api.PresignURL, *Response, error) {
+	return b.client.Build.GetPresignedPutURL(ctx, objName, b.org, b.repo, b.build)
+}
+
+// Upload uploads the object to the storage for the build using a presigned
+// URL. The size is the length of the content, or -1 when it is unknown, in
+// which case the content is read into memory to find its length. Uploads
+// are not limited by the timeout for the HTTP client, only by the context.
+func (b *BuildHandle) Upload(ctx context.Context, objName string, r io.Reader, size int64) error {
+	// presigned URLs reject uploads without a
+	// length, so the content is read to find it
+	if size < 0 {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("unable to read %s: %w", objName, err)
+		}
+
+		r, size = bytes.NewReader(data), int64(len(data))
+	}
+
+	// a body without content is sent as chunked
+	if size == 0 {
+		r = http.NoBody
+	}
+
+	p, _, err := b.UploadURL(ctx, objName)
+	if err != nil {
+		return fmt.Errorf("unable to get upload URL for %s: %w", objName, err)
+	}
+
+	if len(p.URL) == 0 {
+		return fmt.Errorf("unable to get upload URL for %s: no URL returned", objName)
+	}
+
+	// the presigned URL carries the authorization,
+	// so the request is sent without the credentials
+	// for the client
+	req, err := http.NewRequestWithContext(ctx, "PUT", p.URL, r)
+	if err != nil {
+		return err
+	}
+
+	req.ContentLength = size
+
+	// the upload can take longer than the timeout for
+	// the client, so only the transport is shared
+	hc := &http.Client{Transport: b.client.client.Transport}
+
+	resp, err := hc.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to upload %s: %w", objName, err)
+	}
+
+	defer resp.Body.Close()
+
+	// storage providers do not return errors in the format for the API
+	if resp.StatusCode < http.StatusOK || resp.StatusCode > 299 {
+		return fmt.Errorf("unable to upload %s: %s", objName, resp.Status)
+	}
+
+	return nil
+}
+
+// Steps returns the steps for the build.
+func (b *BuildHandle) Steps() *BuildSteps {
+	return &BuildSteps{build: b}
+}
+
+// Services returns the services for the build.
+func (b *BuildHandle) Services() *BuildServices {
+	return &BuildServices{build: b}
+}
+
+// Logs returns the logs for the build.
+func (b *BuildHandle) Logs() *BuildLogs {
+	return &BuildLogs{build: b}
+}
+
+// BuildSteps provides the steps for a build.
+type BuildSteps struct {
+	build *BuildHandle
+}
+
+// Get returns the provided step.
+func (s *BuildSteps) Get(ctx context.Context, step int32) (*api.Step, *Response, error) {
+	return s.build.client.Step.Get(ctx, s.build.org, s.build.repo, s.build.build, step)
+}
+
+// GetAll returns a list of all steps.
+func (s *BuildSteps) GetAll(ctx context.Context, opt *ListOptions) (*[]api.Step, *Response, error) {
+	return s.build.client.Step.GetAll(ctx, s.build.org, s.build.repo, s.build.build, opt)
+}
+
+// Add constructs a step with the provided details.
+func (s *BuildSteps) Add(ctx context.Context, step *api.Step) (*api.Step, *Response, error) {
+	return s.build.client.Step.add(ctx, s.build.org, s.build.repo, s.build.build, step)
+}
+
+// Update modifies a step with the provided details.
+func (s *BuildSteps) Update(ctx context.Context, step *api.Step) (*api.Step, *Response, error) {
+	return s.build.client.Step.Update(ctx, s.build.org, s.build.repo, s.build.build, step)
+}
+
+// Remove deletes the provided step.
+func (s *BuildSteps) Remove(ctx context.Context, step int32) (*string, *Response, error) {
+	return s.build.client.Step.remove(ctx, s.build.org, s.build.repo, s.build.build, step)
+}
+
+// BuildServices provides the services for a build.
+type BuildServices struct {
+	build *BuildHandle
+}
+
+// Get returns the provided service.
+func (s *BuildServices) Get(ctx context.Context, service int32) (*api.Service, *Response, error) {
+	return s.build.client.Svc.Get(ctx, s.build.org, s.build.repo, s.build.build, service)
+}
+
+// GetAll returns a list of all services.
+func (s *BuildServices) GetAll(ctx context.Context, opt *ListOptions) (*[]api.Service, *Response, error) {
+	return s.build.client.Svc.GetAll(ctx, s.build.org, s.build.repo, s.build.build, opt)
+}
+
+// Add constructs a service with the provided details.
+func (s *BuildServices) Add(ctx context.Context, service *api.Service) (*api.Service, *Response, error) {
+	return s.build.client.Svc.add(ctx, s.build.org, s.build.repo, s.build.build, service)
+}
+
+// Update modifies a service with the provided details.
+func (s *BuildServices) Update(ctx context.Context, service *api.Service) (*api.Service, *Response, error) {
+	return s.build.client.Svc.Update(ctx, s.build.org, s.build.repo, s.build.build, service)
+}
+
+// Remove deletes the provided service.
+func (s *BuildServices) Remove(ctx context.Context, service int32) (*string, *Response, error) {
+	return s.build.client.Svc.remove(ctx, s.build.org, s.build.repo, s.build.build, service)
+}
+
+// BuildLogs provides the logs for a build.
+type BuildLogs struct {
+	build *BuildHandle
+}
+
+// GetAll returns a list of all logs for the build.
+func (l *BuildLogs) GetAll(ctx context.Context, opt *ListOptions) (*[]api.Log, *Response, error) {
+	return l.build.client.Build.GetLogs(ctx, l.build.org, l.build.repo, l.build.build, opt)
+}
+
+// GetStep returns the provided step log.
+func (l *BuildLogs) GetStep(ctx context.Context, step int32) (*api.Log, *Response, error) {
+	return l.build.client.Log.GetStep(ctx, l.build.org, l.build.repo, l.build.build, step)
+}
+
+// AddStep constructs a step log with the provided details.
+func (l *BuildLogs) AddStep(ctx context.Context, step int32, log *api.Log) (*Response, error) {
+	return l.build.client.Log.addStep(ctx, l.build.org, l.build.repo, l.build.build, step, log)
+}
+
+// UpdateStep modifies a step log with the provided details.
+func (l *BuildLogs) UpdateStep(ctx context.Context, step int32, log *api.Log) (*Response, error) {
+	return l.build.client.Log.UpdateStep(ctx, l.build.org, l.build.repo, l.build.build, step, log)
+}
+
+// RemoveStep deletes the provided step log.
+func (l *BuildLogs) RemoveStep(ctx context.Context, step int32) (*string, *Response, error) {
+	return l.build.client.Log.removeStep(ctx, l.build.org, l.build.repo, l.build.build, step)
+}
+
+// StepWriter returns a LogWriter uploading the log for the step.
+// The writer must be closed to upload the remaining output.
+func (l *BuildLogs) StepWriter(ctx context.Context, step int32, opt *LogWriterOptions) *LogWriter {
+	return l.build.client.Log.StepWriter(ctx, l.build.org, l.build.repo, l.build.build, step, opt)
+}
+
+// GetService returns the provided service log.
+func (l *BuildLogs) GetService(ctx context.Context, service int32) (*api.Log, *Response, error) {
+	return l.build.client.Log.GetService(ctx, l.build.org, l.build.repo, l.build.build, service)
+}
+
+// AddService constructs a service log with the provided details.
+func (l *BuildLogs) AddService(ctx context.Context, service int32, log *api.Log) (*Response, error) {
+	return l.build.client.Log.addService(ctx, l.build.org, l.build.repo, l.build.build, service, log)
+}
+
+// UpdateService modifies a service log with the provided details.
+func (l *BuildLogs) UpdateService(ctx context.Context, service int32, log *api.Log) (*Response, error) {
+	return l.build.client.Log.UpdateService(ctx, l.build.org, l.build.repo, l.build.build, service, log)
+}
+
+// RemoveService deletes the provided service log.
+func (l *BuildLogs) RemoveService(ctx context.Context, service int32) (*string, *Response, error) {
+	return l.build.client.Log.removeService(ctx, l.build.org, l.build.repo, l.build.build, service)
+}
+
+// ServiceWriter returns a LogWriter uploading the log for the service.
+// The writer must be closed to upload the remaining output.
+func (l *BuildLogs) ServiceWriter(ctx context.Context, service int32, opt *LogWriterOptions) *LogWriter {
+	return l.build.client.Log.ServiceWriter(ctx, l.build.org, l.build.repo, l.build.build, service, opt)
+}
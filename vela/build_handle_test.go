@@ -0,0 +1,262 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	api "github.com/go-vela/server/api/types"
+)
+
+func TestBuildHandle(t *testing.T) {
+	// setup types
+	var (
+		got  string
+		body map[string]any
+	)
+
+	s := recordServer(t, &got, &body)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	b := c.ForRepo("github", "octocat").Build(1)
+
+	tests := []struct {
+		name string
+		call func(ctx context.Context) error
+		want string
+	}{
+		{
+			name: "build",
+			call: func(ctx context.Context) error { _, _, err := b.Get(ctx); return err },
+			want: "GET /api/v1/repos/github/octocat/builds/1",
+		},
+		{
+			name: "executable",
+			call: func(ctx context.Context) error { _, _, err := b.Executable(ctx); return err },
+			want: "GET /api/v1/repos/github/octocat/builds/1/executable",
+		},
+		{
+			name: "token",
+			call: func(ctx context.Context) error { _, _, err := b.Token(ctx); return err },
+			want: "GET /api/v1/repos/github/octocat/builds/1/token",
+		},
+		{
+			name: "id token",
+			call: func(ctx context.Context) error { _, _, err := b.IDToken(ctx, nil); return err },
+			want: "GET /api/v1/repos/github/octocat/builds/1/id_token",
+		},
+		{
+			name: "install token",
+			call: func(ctx context.Context) error { _, _, err := b.InstallToken(ctx, new(api.TokenRequest)); return err },
+			want: "POST /api/v1/repos/github/octocat/builds/1/install_token",
+		},
+		{
+			name: "upload url",
+			call: func(ctx context.Context) error { _, _, err := b.UploadURL(ctx, "file.txt"); return err },
+			want: "PUT /api/v1/repos/github/octocat/builds/1/storage/file.txt/upload-url",
+		},
+		{
+			name: "add step",
+			call: func(ctx context.Context) error { _, _, err := b.Steps().Add(ctx, new(api.Step)); return err },
+			want: "POST /api/v1/repos/github/octocat/builds/1/steps",
+		},
+		{
+			name: "remove step",
+			call: func(ctx context.Context) error { _, _, err := b.Steps().Remove(ctx, 2); return err },
+			want: "DELETE /api/v1/repos/github/octocat/builds/1/steps/2",
+		},
+		{
+			name: "service",
+			call: func(ctx context.Context) error { _, _, err := b.Services().Get(ctx, 2); return err },
+			want: "GET /api/v1/repos/github/octocat/builds/1/services/2",
+		},
+		{
+			name: "logs",
+			call: func(ctx context.Context) error { _, _, err := b.Logs().GetAll(ctx, nil); return err },
+			want: "GET /api/v1/repos/github/octocat/builds/1/logs",
+		},
+		{
+			name: "add step log",
+			call: func(ctx context.Context) error { _, err := b.Logs().AddStep(ctx, 2, new(api.Log)); return err },
+			want: "POST /api/v1/repos/github/octocat/builds/1/steps/2/logs",
+		},
+		{
+			name: "remove service log",
+			call: func(ctx context.Context) error { _, _, err := b.Logs().RemoveService(ctx, 2); return err },
+			want: "DELETE /api/v1/repos/github/octocat/builds/1/services/2/logs",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// run test
+			err := test.call(t.Context())
+			if err != nil {
+				t.Errorf("%s returned err: %v", test.name, err)
+			}
+
+			if got != test.want {
+				t.Errorf("%s sent %s, want %s", test.name, got, test.want)
+			}
+		})
+	}
+}
+
+func TestBuildHandle_Upload(t *testing.T) {
+	// setup types
+	var (
+		uploaded string
+		length   int64
+		auth     string
+		status   = http.StatusOK
+	)
+
+	s := fakeServer(t, func(mux *http.ServeMux) {
+		mux.HandleFunc("PUT /api/v1/repos/{org}/{repo}/builds/{build}/storage/{object}/upload-url", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprintf(w, `{"url":"http://%s/bucket/%s?X-Amz-Signature=abc"}`, r.Host, r.PathValue("object"))
+		})
+
+		mux.HandleFunc("PUT /bucket/{object}", func(w http.ResponseWriter, r *http.Request) {
+			// uploads outlive the timeout for the client
+			time.Sleep(100 * time.Millisecond)
+
+			b, _ := io.ReadAll(r.Body)
+
+			uploaded = r.PathValue("object") + ":" + string(b)
+			length = r.ContentLength
+			auth = r.Header.Get("Authorization")
+
+			w.WriteHeader(status)
+		})
+	})
+
+	c, _ := NewClient(s.URL, "", &http.Client{Timeout: 50 * time.Millisecond})
+	c.Authentication.SetTokenAuth("token")
+
+	b := c.ForBuild("github", "octocat", 1)
+
+	// run test
+	err := b.Upload(t.Context(), "file.txt", strings.NewReader("Hello, world!"), 13)
+	if err != nil {
+		t.Fatalf("Upload returned err: %v", err)
+	}
+
+	if uploaded != "file.txt:Hello, world!" {
+		t.Errorf("Upload uploaded %q, want the object", uploaded)
+	}
+
+	if len(auth) > 0 {
+		t.Errorf("Upload should not have sent the credentials for the client to the storage")
+	}
+
+	// content of an unknown size is sent with its length
+	err = b.Upload(t.Context(), "other.txt", io.MultiReader(strings.NewReader("Hello, world!")), -1)
+	if err != nil {
+		t.Fatalf("Upload returned err: %v", err)
+	}
+
+	if uploaded != "other.txt:Hello, world!" || length != 13 {
+		t.Errorf("Upload uploaded %q with length %d, want the object with its length", uploaded, length)
+	}
+
+	status = http.StatusForbidden
+
+	err = b.Upload(t.Context(), "file.txt", strings.NewReader("Hello, world!"), 13)
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Upload should have returned err for the rejected upload, got %v", err)
+	}
+}
+
+func TestBuildHandle_DeprecatedShims(t *testing.T) {
+	// setup types
+	var (
+		got  string
+		body map[string]any
+	)
+
+	s := recordServer(t, &got, &body)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	b := c.ForBuild("github", "octocat", 1)
+
+	tests := []struct {
+		name   string
+		shim   func(ctx context.Context) error
+		handle func(ctx context.Context) error
+	}{
+		{
+			name:   "remove build",
+			shim:   func(ctx context.Context) error { _, _, err := c.Build.Remove(ctx, "github", "octocat", 1); return err },
+			handle: func(ctx context.Context) error { _, _, err := b.Remove(ctx); return err },
+		},
+		{
+			name: "id token",
+			shim: func(ctx context.Context) error {
+				_, _, err := c.Build.GetIDToken(ctx, "github", "octocat", 1, nil)
+				return err
+			},
+			handle: func(ctx context.Context) error { _, _, err := b.IDToken(ctx, nil); return err },
+		},
+		{
+			name: "remove step",
+			shim: func(ctx context.Context) error {
+				_, _, err := c.Step.Remove(ctx, "github", "octocat", 1, 2)
+				return err
+			},
+			handle: func(ctx context.Context) error { _, _, err := b.Steps().Remove(ctx, 2); return err },
+		},
+		{
+			name: "add service",
+			shim: func(ctx context.Context) error {
+				_, _, err := c.Svc.Add(ctx, "github", "octocat", 1, new(api.Service))
+				return err
+			},
+			handle: func(ctx context.Context) error { _, _, err := b.Services().Add(ctx, new(api.Service)); return err },
+		},
+		{
+			name: "add service log",
+			shim: func(ctx context.Context) error {
+				_, err := c.Log.AddService(ctx, "github", "octocat", 1, 2, new(api.Log))
+				return err
+			},
+			handle: func(ctx context.Context) error { _, err := b.Logs().AddService(ctx, 2, new(api.Log)); return err },
+		},
+		{
+			name: "remove step log",
+			shim: func(ctx context.Context) error {
+				_, _, err := c.Log.RemoveStep(ctx, "github", "octocat", 1, 2)
+				return err
+			},
+			handle: func(ctx context.Context) error { _, _, err := b.Logs().RemoveStep(ctx, 2); return err },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// run test
+			err := test.shim(t.Context())
+			if err != nil {
+				t.Errorf("%s returned err: %v", test.name, err)
+			}
+
+			want := got
+
+			err = test.handle(t.Context())
+			if err != nil {
+				t.Errorf("%s returned err: %v", test.name, err)
+			}
+
+			if got != want {
+				t.Errorf("%s sent %s, want %s", test.name, got, want)
+			}
+		})
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/go-querystring/query"
@@ -39,6 +40,15 @@ type (
 		// User agent used when communicating with the Vela API.
 		UserAgent string
 
+		// Number of times the OIDC keys were rotated with the client.
+		oidcRotations atomic.Uint64
+
+		// Routes the builds in the queue were queued on.
+		queueRoutes queueRoutes
+
+		// Masker applied to every log uploaded with the client.
+		logMasker atomic.Pointer[SecretMasker]
+
 		// Vela service for authentication.
 		Admin          *AdminService
 		Authentication *AuthenticationService
@@ -48,6 +58,7 @@ type (
 		Deployment     *DeploymentService
 		Hook           *HookService
 		Log            *LogService
+		OIDC           *OIDCService
 		Pipeline       *PipelineService
 		Repo           *RepoService
 		SCM            *SCMService
@@ -147,6 +158,7 @@ func NewClient(baseURL, id string, httpClient *http.Client) (*Client, error) {
 	c.Deployment = &DeploymentService{client: c}
 	c.Hook = &HookService{client: c}
 	c.Log = &LogService{client: c}
+	c.OIDC = &OIDCService{client: c}
 	c.Pipeline = &PipelineService{client: c}
 	c.Repo = &RepoService{client: c}
 	c.SCM = &SCMService{client: c}
@@ -166,6 +178,14 @@ func (c *Client) SetTimeout(d time.Duration) {
 	c.client.Timeout = d
 }
 
+// Close stops the background work for the client, such as
+// the token refresher. The client can still send requests.
+func (c *Client) Close() error {
+	c.Authentication.StopRefresher()
+
+	return nil
+}
+
 // buildURLForRequest will build the URL (as a string) that will be called.
 // It does several cleaning tasks for us.
 func (c *Client) buildURLForRequest(urlStr string) (string, error) {
@@ -209,11 +229,11 @@ func (c *Client) addAuthentication(ctx context.Context, req *http.Request) error
 			return err
 		}
 
-		isExpired := IsTokenExpired(currentAccess)
+		isExpired := c.Authentication.isTokenExpired(currentAccess)
 		if isExpired {
 			logrus.Debug("access token has expired")
 
-			isRefreshExpired := IsTokenExpired(currentRefresh)
+			isRefreshExpired := c.Authentication.isTokenExpired(currentRefresh)
 			if isRefreshExpired {
 				return fmt.Errorf("your tokens have expired - please log in again with 'vela login'")
 			}
@@ -274,6 +294,36 @@ func (c *Client) addAuthentication(ctx context.Context, req *http.Request) error
 	return nil
 }
 
+// listAll collects every page of results from the provided list
+// function, stopping once a page returns fewer than perPage results.
+func listAll[T any](perPage int, list func(opt ListOptions) (*[]T, *Response, error)) ([]T, error) {
+	// use the maximum page size allowed by the server if none is provided,
+	// and never more, since the server returns at most 100 results and
+	// a larger page size makes the first page look like the last
+	if perPage <= 0 || perPage > 100 {
+		perPage = 100
+	}
+
+	var all []T
+
+	for page := 1; ; page++ {
+		v, _, err := list(ListOptions{Page: page, PerPage: perPage})
+		if err != nil {
+			return all, err
+		}
+
+		if v == nil {
+			return all, nil
+		}
+
+		all = append(all, *v...)
+
+		if len(*v) < perPage {
+			return all, nil
+		}
+	}
+}
+
 // addOptions adds the parameters in opt as url query parameters to s.
 // opt must be a struct whose fields may contain "url" tags.
 func addOptions(s string, opt any) (string, error) {
@@ -497,12 +547,33 @@ func (c *Client) CallWithHeaders(ctx context.Context, method, url string, body,
 // or returned as an error if an API error has occurred.
 // If respType implements the io.Writer interface, the raw response body will
 // be written to respType, without attempting to first decode it.
+// When a request authenticated with an access and refresh token pair or a
+// personal access token is rejected as unauthorized, the credentials are
+// renewed and the request is sent once more.
 func (c *Client) Do(req *http.Request, respType any) (*Response, error) {
 	// send request with client
 	resp, err := c.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
+
+	// renew the credentials and retry the request once
+	// when the server rejects them, such as when the
+	// token was revoked or the clocks are out of sync
+	if resp.StatusCode == http.StatusUnauthorized && c.canReauthenticate(req) {
+		retry, err := c.reauthenticate(req)
+		if err != nil {
+			logrus.Debugf("unable to renew credentials for unauthorized request: %v", err)
+		} else {
+			resp.Body.Close()
+
+			resp, err = c.client.Do(retry)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	// defer closing response body
 	defer resp.Body.Close()
 
@@ -553,6 +624,80 @@ func (c *Client) Do(req *http.Request, respType any) (*Response, error) {
 	return response, err
 }
 
+// canReauthenticate checks if the credentials for the request can be
+// renewed and the request sent again. Only requests authenticated by the
+// client with an access and refresh token pair or a personal access token
+// are retried, and only when the body of the request can be rebuilt.
+func (c *Client) canReauthenticate(req *http.Request) bool {
+	if !c.Authentication.HasAccessAndRefreshAuth() && !c.Authentication.HasPersonalAccessTokenAuth() {
+		return false
+	}
+
+	// requests renewing the credentials are not authenticated
+	// by the client, which keeps them from being retried
+	if len(req.Header.Get("Authorization")) == 0 {
+		return false
+	}
+
+	// streaming bodies can only be sent once
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// reauthenticate renews the credentials for the client and returns a copy
+// of the request authenticated with them. The access token is only refreshed
+// when the request was sent with the current access token, so concurrent
+// requests rejected with the same token refresh it once between them.
+func (c *Client) reauthenticate(req *http.Request) (*http.Request, error) {
+	ctx := req.Context()
+
+	if c.Authentication.HasAccessAndRefreshAuth() {
+		access, err := c.Authentication.getAccessToken()
+		if err != nil {
+			return nil, err
+		}
+
+		if req.Header.Get("Authorization") == fmt.Sprintf("Bearer %s", access) {
+			refresh, err := c.Authentication.getRefreshToken()
+			if err != nil {
+				return nil, err
+			}
+
+			logrus.Debug("access token was rejected, fetching new access token with existing refresh token")
+
+			// send API call to refresh the access token to Vela
+			//
+			// https://pkg.go.dev/github.com/go-vela/sdk-go/vela?tab=doc#AuthenticationService.RefreshAccessToken
+			_, err = c.Authentication.RefreshAccessToken(ctx, refresh)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	retry := req.Clone(ctx)
+
+	// rebuild the body consumed by the first request
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+
+		retry.Body = body
+	}
+
+	// personal access tokens are exchanged
+	// again when authenticating the request
+	retry.Header.Del("Authorization")
+
+	err := c.addAuthentication(ctx, retry)
+	if err != nil {
+		return nil, err
+	}
+
+	return retry, nil
+}
+
 // CheckResponse checks the API response for errors, and returns them if present.
 // A response is considered an error if it has a status code outside the 200 range.
 func CheckResponse(r *http.Response) error {
@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Environments a client can be created from.
+const (
+	// EnvModeBuild defines the environment for
+	// steps and services running in a build.
+	EnvModeBuild = "build"
+
+	// EnvModeWorker defines the environment for workers.
+	EnvModeWorker = "worker"
+
+	// EnvModeCLI defines the environment for
+	// users running tools from a terminal.
+	EnvModeCLI = "cli"
+)
+
+// EnvMode returns the environment the process is running in. Builds
+// are detected by VELA_BUILD_TOKEN or VELA_BUILD_NUMBER, workers by
+// VELA_SERVER_ADDR, and any other environment is treated as the CLI.
+func EnvMode() string {
+	switch {
+	case len(os.Getenv("VELA_BUILD_TOKEN")) > 0, len(os.Getenv("VELA_BUILD_NUMBER")) > 0:
+		return EnvModeBuild
+	case len(os.Getenv("VELA_SERVER_ADDR")) > 0:
+		return EnvModeWorker
+	}
+
+	return EnvModeCLI
+}
+
+// NewClientFromEnv returns a new Vela API client configured from the
+// environment variables for the environment the process is running in:
+//
+//   - build: VELA_ADDR, VELA_BUILD_TOKEN, VELA_NETRC_PASSWORD,
+//     VELA_REPO_FULL_NAME and VELA_BUILD_NUMBER for build token auth
+//   - worker: VELA_SERVER_ADDR and VELA_SERVER_SECRET for token auth
+//   - cli: VELA_ADDR with VELA_ACCESS_TOKEN and VELA_REFRESH_TOKEN,
+//     or VELA_TOKEN, otherwise the current profile from the CLI config
+//
+// When variables are missing, the error lists all of them.
+func NewClientFromEnv() (*Client, error) {
+	switch EnvMode() {
+	case EnvModeBuild:
+		return newBuildClientFromEnv()
+	case EnvModeWorker:
+		return newWorkerClientFromEnv()
+	}
+
+	return newCLIClientFromEnv()
+}
+
+// newBuildClientFromEnv returns a client with build token auth.
+func newBuildClientFromEnv() (*Client, error) {
+	env, err := requireEnv(EnvModeBuild, "VELA_ADDR", "VELA_BUILD_TOKEN", "VELA_NETRC_PASSWORD", "VELA_REPO_FULL_NAME", "VELA_BUILD_NUMBER")
+	if err != nil {
+		return nil, err
+	}
+
+	build, err := strconv.ParseInt(env["VELA_BUILD_NUMBER"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VELA_BUILD_NUMBER %s: %w", env["VELA_BUILD_NUMBER"], err)
+	}
+
+	c, err := NewClient(env["VELA_ADDR"], "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Authentication.SetBuildTokenAuth(env["VELA_BUILD_TOKEN"], env["VELA_NETRC_PASSWORD"], 0, env["VELA_REPO_FULL_NAME"], build)
+
+	return c, nil
+}
+
+// newWorkerClientFromEnv returns a client with token auth for the server secret.
+func newWorkerClientFromEnv() (*Client, error) {
+	env, err := requireEnv(EnvModeWorker, "VELA_SERVER_ADDR", "VELA_SERVER_SECRET")
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := NewClient(env["VELA_SERVER_ADDR"], "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Authentication.SetTokenAuth(env["VELA_SERVER_SECRET"])
+
+	return c, nil
+}
+
+// newCLIClientFromEnv returns a client with the tokens from the
+// environment, or for the current profile when none are set.
+func newCLIClientFromEnv() (*Client, error) {
+	switch {
+	case len(os.Getenv("VELA_ACCESS_TOKEN")) > 0, len(os.Getenv("VELA_REFRESH_TOKEN")) > 0:
+		env, err := requireEnv(EnvModeCLI, "VELA_ADDR", "VELA_ACCESS_TOKEN", "VELA_REFRESH_TOKEN")
+		if err != nil {
+			return nil, err
+		}
+
+		c, err := NewClient(env["VELA_ADDR"], "", nil)
+		if err != nil {
+			return nil, err
+		}
+
+		c.Authentication.SetAccessAndRefreshAuth(env["VELA_ACCESS_TOKEN"], env["VELA_REFRESH_TOKEN"])
+
+		return c, nil
+	case len(os.Getenv("VELA_TOKEN")) > 0:
+		env, err := requireEnv(EnvModeCLI, "VELA_ADDR", "VELA_TOKEN")
+		if err != nil {
+			return nil, err
+		}
+
+		c, err := NewClient(env["VELA_ADDR"], "", nil)
+		if err != nil {
+			return nil, err
+		}
+
+		c.Authentication.SetTokenAuth(env["VELA_TOKEN"])
+
+		return c, nil
+	}
+
+	p, err := LoadProfiles("")
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := p.Client("", "", nil)
+	if errors.Is(err, ErrNoCredentials) {
+		return nil, fmt.Errorf("missing environment variables for %s: VELA_ADDR, VELA_ACCESS_TOKEN, VELA_REFRESH_TOKEN (%w)", EnvModeCLI, err)
+	}
+
+	return c, err
+}
+
+// requireEnv returns the values of the environment variables,
+// or an error listing the variables that are not set.
+func requireEnv(mode string, names ...string) (map[string]string, error) {
+	env := make(map[string]string)
+
+	var missing []string
+
+	for _, name := range names {
+		v := os.Getenv(name)
+		if len(v) == 0 {
+			missing = append(missing, name)
+
+			continue
+		}
+
+		env[name] = v
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing environment variables for %s: %s", mode, strings.Join(missing, ", "))
+	}
+
+	return env, nil
+}
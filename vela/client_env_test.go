@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// clearVelaEnv clears the environment variables read by NewClientFromEnv.
+func clearVelaEnv(t *testing.T) {
+	t.Helper()
+
+	for _, name := range []string{
+		"VELA_ADDR", "VELA_BUILD_TOKEN", "VELA_NETRC_PASSWORD", "VELA_REPO_FULL_NAME", "VELA_BUILD_NUMBER",
+		"VELA_SERVER_ADDR", "VELA_SERVER_SECRET", "VELA_ACCESS_TOKEN", "VELA_REFRESH_TOKEN", "VELA_TOKEN",
+		ProfileEnv,
+	} {
+		t.Setenv(name, "")
+	}
+
+	// keep the config for the user running the tests out of the way
+	t.Setenv("VELA_CONFIG", filepath.Join(t.TempDir(), "config.yml"))
+}
+
+func TestNewClientFromEnv_Build(t *testing.T) {
+	clearVelaEnv(t)
+
+	t.Setenv("VELA_ADDR", "https://vela.example.com")
+	t.Setenv("VELA_BUILD_TOKEN", "build")
+	t.Setenv("VELA_NETRC_PASSWORD", "scm")
+	t.Setenv("VELA_REPO_FULL_NAME", "github/octocat")
+	t.Setenv("VELA_BUILD_NUMBER", "1")
+
+	// run test
+	c, err := NewClientFromEnv()
+	if err != nil {
+		t.Fatalf("NewClientFromEnv returned err: %v", err)
+	}
+
+	if !c.Authentication.HasBuildTokenAuth() || c.Authentication.SCMToken() != "scm" {
+		t.Errorf("NewClientFromEnv should have set build token auth")
+	}
+
+	if *c.Authentication.buildRepo != "github/octocat" || *c.Authentication.buildNumber != 1 {
+		t.Errorf("NewClientFromEnv set build %s/%d", *c.Authentication.buildRepo, *c.Authentication.buildNumber)
+	}
+}
+
+func TestNewClientFromEnv_Build_Missing(t *testing.T) {
+	clearVelaEnv(t)
+
+	t.Setenv("VELA_ADDR", "https://vela.example.com")
+	t.Setenv("VELA_BUILD_NUMBER", "1")
+
+	// run test
+	_, err := NewClientFromEnv()
+	if err == nil {
+		t.Fatalf("NewClientFromEnv should have returned err")
+	}
+
+	want := "missing environment variables for build: VELA_BUILD_TOKEN, VELA_NETRC_PASSWORD, VELA_REPO_FULL_NAME"
+
+	if err.Error() != want {
+		t.Errorf("NewClientFromEnv returned err %q, want %q", err, want)
+	}
+}
+
+func TestNewClientFromEnv_Worker(t *testing.T) {
+	clearVelaEnv(t)
+
+	t.Setenv("VELA_SERVER_ADDR", "https://vela.example.com")
+	t.Setenv("VELA_SERVER_SECRET", "secret")
+
+	// run test
+	c, err := NewClientFromEnv()
+	if err != nil {
+		t.Fatalf("NewClientFromEnv returned err: %v", err)
+	}
+
+	if !c.Authentication.HasTokenAuth() || *c.Authentication.token != "secret" {
+		t.Errorf("NewClientFromEnv should have set token auth")
+	}
+}
+
+func TestNewClientFromEnv_CLI(t *testing.T) {
+	clearVelaEnv(t)
+
+	t.Setenv("VELA_ADDR", "https://vela.example.com")
+	t.Setenv("VELA_ACCESS_TOKEN", "access")
+	t.Setenv("VELA_REFRESH_TOKEN", "refresh")
+
+	// run test
+	c, err := NewClientFromEnv()
+	if err != nil {
+		t.Fatalf("NewClientFromEnv returned err: %v", err)
+	}
+
+	if !c.Authentication.HasAccessAndRefreshAuth() {
+		t.Errorf("NewClientFromEnv should have set access and refresh auth")
+	}
+}
+
+func TestNewClientFromEnv_CLI_Profile(t *testing.T) {
+	clearVelaEnv(t)
+
+	p, _ := LoadProfiles("")
+
+	_ = p.Set(DefaultProfile, &Credentials{Address: "https://vela.example.com", Token: "token"})
+
+	// run test
+	c, err := NewClientFromEnv()
+	if err != nil {
+		t.Fatalf("NewClientFromEnv returned err: %v", err)
+	}
+
+	if c.baseURL.String() != "https://vela.example.com" || !c.Authentication.HasTokenAuth() {
+		t.Errorf("NewClientFromEnv should have used the default profile")
+	}
+}
+
+func TestNewClientFromEnv_CLI_Missing(t *testing.T) {
+	clearVelaEnv(t)
+
+	// run test
+	_, err := NewClientFromEnv()
+	if err == nil || !strings.Contains(err.Error(), "VELA_ADDR, VELA_ACCESS_TOKEN, VELA_REFRESH_TOKEN") {
+		t.Errorf("NewClientFromEnv should have returned err listing the variables, got %v", err)
+	}
+}
@@ -16,6 +16,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gin-gonic/gin"
+	jwt "github.com/golang-jwt/jwt/v5"
+
 	"github.com/go-vela/sdk-go/version"
 	"github.com/go-vela/server/mock/server"
 )
@@ -55,6 +58,7 @@ func TestVela_NewClient(t *testing.T) {
 	want.Deployment = &DeploymentService{client: want}
 	want.Hook = &HookService{client: want}
 	want.Log = &LogService{client: want}
+	want.OIDC = &OIDCService{client: want}
 	want.Pipeline = &PipelineService{client: want}
 	want.Repo = &RepoService{client: want}
 	want.SCM = &SCMService{client: want}
@@ -458,6 +462,179 @@ func TestVela_addAuthentication_AccessAndRefresh_MissingAccessToken(t *testing.T
 	}
 }
 
+// reauthServer is a minimal stand-in for the Vela API rejecting
+// requests that are not authenticated with the accepted token.
+type reauthServer struct {
+	accept  string
+	refresh string
+
+	requests  atomic.Int32
+	refreshes atomic.Int32
+	exchanges atomic.Int32
+	bodies    []string
+	mu        sync.Mutex
+}
+
+func (rs *reauthServer) routes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /token-refresh", func(w http.ResponseWriter, _ *http.Request) {
+		rs.refreshes.Add(1)
+
+		_, _ = fmt.Fprintf(w, `{"token":%q}`, rs.refresh)
+	})
+
+	mux.HandleFunc("POST /authenticate/token", func(w http.ResponseWriter, _ *http.Request) {
+		n := rs.exchanges.Add(1)
+
+		_, _ = fmt.Fprintf(w, `{"token":"access-%d"}`, n)
+	})
+
+	mux.HandleFunc("POST /api/v1/echo", func(w http.ResponseWriter, r *http.Request) {
+		rs.requests.Add(1)
+
+		body, _ := io.ReadAll(r.Body)
+
+		rs.mu.Lock()
+		rs.bodies = append(rs.bodies, string(body))
+		rs.mu.Unlock()
+
+		if r.Header.Get("Authorization") != "Bearer "+rs.accept {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"unauthorized"}`))
+
+			return
+		}
+
+		_, _ = w.Write(body)
+	})
+}
+
+func TestVela_Do_Unauthorized_AccessAndRefresh(t *testing.T) {
+	// setup types
+	refreshed := makeSampleToken(jwt.MapClaims{"exp": float64(time.Now().Unix() + 200)})
+	rs := &reauthServer{accept: refreshed, refresh: refreshed}
+
+	s := fakeServer(t, rs.routes)
+
+	c, _ := NewClient(s.URL, "", nil)
+	c.Authentication.SetAccessAndRefreshAuth(TestTokenGood, TestTokenGood)
+
+	got := map[string]string{}
+
+	// run test
+	_, err := c.Call(t.Context(), "POST", "/api/v1/echo", map[string]string{"name": "foo"}, &got)
+	if err != nil {
+		t.Fatalf("Call returned err: %v", err)
+	}
+
+	if got["name"] != "foo" {
+		t.Errorf("Call returned %v, want the request body", got)
+	}
+
+	if rs.requests.Load() != 2 || rs.refreshes.Load() != 1 {
+		t.Errorf("Call made %d requests and %d refreshes, want 2 and 1", rs.requests.Load(), rs.refreshes.Load())
+	}
+
+	if rs.bodies[0] != rs.bodies[1] {
+		t.Errorf("Call sent body %q on retry, want %q", rs.bodies[1], rs.bodies[0])
+	}
+
+	access, _ := c.Authentication.getAccessToken()
+	if access != rs.accept {
+		t.Errorf("Call should have stored the refreshed access token")
+	}
+}
+
+func TestVela_Do_Unauthorized_RetryOnce(t *testing.T) {
+	// setup types
+	rs := &reauthServer{accept: "never", refresh: makeSampleToken(jwt.MapClaims{"exp": float64(time.Now().Unix() + 200)})}
+
+	s := fakeServer(t, rs.routes)
+
+	c, _ := NewClient(s.URL, "", nil)
+	c.Authentication.SetAccessAndRefreshAuth(TestTokenGood, TestTokenGood)
+
+	// run test
+	resp, err := c.Call(t.Context(), "POST", "/api/v1/echo", nil, nil)
+	if err == nil {
+		t.Errorf("Call should have returned err")
+	}
+
+	if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Call should have returned the unauthorized response")
+	}
+
+	if rs.requests.Load() != 2 || rs.refreshes.Load() != 1 {
+		t.Errorf("Call made %d requests and %d refreshes, want 2 and 1", rs.requests.Load(), rs.refreshes.Load())
+	}
+}
+
+func TestVela_Do_Unauthorized_PersonalAccessToken(t *testing.T) {
+	// setup types
+	rs := &reauthServer{accept: "access-2"}
+
+	s := fakeServer(t, rs.routes)
+
+	c, _ := NewClient(s.URL, "", nil)
+	c.Authentication.SetPersonalAccessTokenAuth("pat")
+
+	// run test
+	_, err := c.Call(t.Context(), "POST", "/api/v1/echo", nil, nil)
+	if err != nil {
+		t.Fatalf("Call returned err: %v", err)
+	}
+
+	if rs.requests.Load() != 2 || rs.exchanges.Load() != 2 {
+		t.Errorf("Call made %d requests and %d exchanges, want 2 and 2", rs.requests.Load(), rs.exchanges.Load())
+	}
+}
+
+func TestVela_Do_Unauthorized_NoRetry(t *testing.T) {
+	// setup types
+	rs := &reauthServer{accept: "never"}
+
+	s := fakeServer(t, rs.routes)
+
+	tests := []struct {
+		name string
+		auth func(*Client)
+		body any
+	}{
+		{
+			name: "plain token",
+			auth: func(c *Client) { c.Authentication.SetTokenAuth("token") },
+		},
+		{
+			name: "build token",
+			auth: func(c *Client) { c.Authentication.SetBuildTokenAuth("build", "scm", 0, "github/octocat", 1) },
+		},
+		{
+			name: "streaming body",
+			auth: func(c *Client) { c.Authentication.SetAccessAndRefreshAuth(TestTokenGood, TestTokenGood) },
+			body: io.NopCloser(strings.NewReader("Hello, world!")),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rs.requests.Store(0)
+			rs.refreshes.Store(0)
+
+			c, _ := NewClient(s.URL, "", nil)
+			test.auth(c)
+
+			// run test
+			_, err := c.Call(t.Context(), "POST", "/api/v1/echo", test.body, nil)
+			if err == nil {
+				t.Errorf("Call should have returned err")
+			}
+
+			if rs.requests.Load() != 1 || rs.refreshes.Load() != 0 {
+				t.Errorf("Call made %d requests and %d refreshes, want 1 and 0", rs.requests.Load(), rs.refreshes.Load())
+			}
+		})
+	}
+}
+
 func TestVela_Call_BadMethod(t *testing.T) {
 	// setup types
 	c, err := NewClient("http://localhost:8080", "", nil)
@@ -688,6 +865,75 @@ func TestVela_addOptions_NilOptions(t *testing.T) {
 	}
 }
 
+func TestVela_listAll(t *testing.T) {
+	// setup types
+	pages := [][]int{{1, 2}, {3, 4}, {5}}
+
+	var got []ListOptions
+
+	// run test
+	all, err := listAll(2, func(opt ListOptions) (*[]int, *Response, error) {
+		got = append(got, opt)
+
+		return &pages[opt.Page-1], nil, nil
+	})
+	if err != nil {
+		t.Errorf("listAll returned err: %v", err)
+	}
+
+	if !reflect.DeepEqual(all, []int{1, 2, 3, 4, 5}) {
+		t.Errorf("listAll is %v, want %v", all, []int{1, 2, 3, 4, 5})
+	}
+
+	if len(got) != 3 || got[2].PerPage != 2 {
+		t.Errorf("listAll requested %v, want 3 pages of 2", got)
+	}
+}
+
+func TestVela_listAll_MaxPerPage(t *testing.T) {
+	// setup types
+	page := make([]int, 100)
+
+	var got []ListOptions
+
+	// run test
+	all, err := listAll(250, func(opt ListOptions) (*[]int, *Response, error) {
+		got = append(got, opt)
+
+		// the server returns at most 100 results per page
+		if opt.Page > 2 {
+			return &[]int{}, nil, nil
+		}
+
+		return &page, nil, nil
+	})
+	if err != nil {
+		t.Errorf("listAll returned err: %v", err)
+	}
+
+	if len(all) != 200 {
+		t.Errorf("listAll returned %d results, want 200", len(all))
+	}
+
+	if len(got) != 3 || got[0].PerPage != 100 {
+		t.Errorf("listAll requested %v, want 3 pages of 100", got)
+	}
+}
+
+func TestVela_listAll_Error(t *testing.T) {
+	// run test
+	_, err := listAll(0, func(opt ListOptions) (*[]int, *Response, error) {
+		if opt.PerPage != 100 {
+			t.Errorf("listAll PerPage is %d, want 100", opt.PerPage)
+		}
+
+		return nil, nil, fmt.Errorf("boom")
+	})
+	if err == nil {
+		t.Errorf("listAll should have returned err")
+	}
+}
+
 func TestResponse_populatePageValues(t *testing.T) {
 	// setup types
 	r := http.Response{
@@ -750,3 +996,46 @@ func TestResponse_populatePageValues_invalid(t *testing.T) {
 		t.Errorf("response.LastPage: %v, want %v", got, want)
 	}
 }
+
+// fakeServer returns a test server for the Vela API serving the routes
+// registered by the provided function, with every other request served
+// by server.FakeHandler. The server is closed when the test ends.
+func fakeServer(t *testing.T, routes func(mux *http.ServeMux)) *httptest.Server {
+	t.Helper()
+
+	// setup context
+	gin.SetMode(gin.TestMode)
+
+	mux := http.NewServeMux()
+	mux.Handle("/", server.FakeHandler())
+
+	if routes != nil {
+		routes(mux)
+	}
+
+	s := httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// mockServer returns a test server for the Vela API served by
+// server.FakeHandler, passing every request to the provided
+// function first. The server is closed when the test ends.
+func mockServer(t *testing.T, record func(r *http.Request)) *httptest.Server {
+	t.Helper()
+
+	// setup context
+	gin.SetMode(gin.TestMode)
+
+	h := server.FakeHandler()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+
+		h.ServeHTTP(w, r)
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
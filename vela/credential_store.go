@@ -0,0 +1,404 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// ErrNoCredentials defines the error returned by a
+// CredentialStore when no credentials are stored.
+var ErrNoCredentials = errors.New("no credentials found")
+
+// Credential authentication types.
+const (
+	// CredentialAuthOAuth defines the authentication
+	// type for access and refresh tokens.
+	CredentialAuthOAuth = "oauth"
+
+	// CredentialAuthToken defines the authentication
+	// type for plain tokens, such as worker tokens.
+	CredentialAuthToken = "token"
+
+	// CredentialAuthPersonalAccessToken defines the authentication
+	// type for personal access tokens from the SCM.
+	CredentialAuthPersonalAccessToken = "personal_access_token"
+)
+
+// Credentials represents the credentials stored for a server.
+type Credentials struct {
+	Address string
+
+	// Type of authentication used for the server. When empty,
+	// the type is inferred from the tokens that are set.
+	AuthType string
+
+	AccessToken         string
+	RefreshToken        string
+	PersonalAccessToken string
+	Token               string
+}
+
+// GetAuthType returns the type of authentication for the credentials,
+// inferring it from the tokens that are set when no type is set.
+func (c *Credentials) GetAuthType() string {
+	switch {
+	case len(c.AuthType) > 0:
+		return c.AuthType
+	case len(c.AccessToken) > 0 || len(c.RefreshToken) > 0:
+		return CredentialAuthOAuth
+	case len(c.PersonalAccessToken) > 0:
+		return CredentialAuthPersonalAccessToken
+	case len(c.Token) > 0:
+		return CredentialAuthToken
+	}
+
+	return ""
+}
+
+// CredentialStore stores the credentials for servers under
+// named profiles, with the empty name for the default profile.
+type CredentialStore interface {
+	// Load returns the credentials for the profile, or an
+	// error wrapping ErrNoCredentials when none are stored.
+	Load(profile string) (*Credentials, error)
+
+	// Save replaces the credentials for the profile.
+	Save(profile string, creds *Credentials) error
+}
+
+// FileCredentialStore is a CredentialStore backed by the YAML
+// config file for the Vela CLI. The default profile is the api
+// section of the config, and named profiles are kept in the
+// profiles section using the same layout. Other settings in
+// the config are preserved when saving credentials.
+type FileCredentialStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// cliConfig represents the parts of the CLI config
+// holding the credentials for servers.
+type cliConfig struct {
+	API      *cliAPI            `yaml:"api"`
+	Profile  string             `yaml:"profile"`
+	Profiles map[string]*cliAPI `yaml:"profiles"`
+}
+
+// cliAPI represents the credentials for a server in the CLI config.
+type cliAPI struct {
+	Address string `yaml:"addr"`
+	Auth    string `yaml:"auth"`
+	Token   struct {
+		Access         string `yaml:"access"`
+		Refresh        string `yaml:"refresh"`
+		PersonalAccess string `yaml:"personal_access"`
+		Value          string `yaml:"value"`
+	} `yaml:"token"`
+}
+
+// NewFileCredentialStore returns a store for the config file at the path.
+// When the path is empty, the default path for the CLI config is used.
+func NewFileCredentialStore(path string) (*FileCredentialStore, error) {
+	if len(path) == 0 {
+		p, err := DefaultCredentialPath()
+		if err != nil {
+			return nil, err
+		}
+
+		path = p
+	}
+
+	return &FileCredentialStore{path: path}, nil
+}
+
+// DefaultCredentialPath returns the path to the config file for the
+// CLI, which is VELA_CONFIG when set or .vela/config.yml in the home
+// directory for the user.
+func DefaultCredentialPath() (string, error) {
+	if p := os.Getenv("VELA_CONFIG"); len(p) > 0 {
+		return p, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to find home directory: %w", err)
+	}
+
+	return filepath.Join(home, ".vela", "config.yml"), nil
+}
+
+// Path returns the path to the config file for the store.
+func (s *FileCredentialStore) Path() string {
+	return s.path
+}
+
+// Load returns the credentials for the profile from the config file.
+func (s *FileCredentialStore) Load(profile string) (*Credentials, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cfg, err := s.readConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	a := cfg.API
+	if len(profile) > 0 {
+		a = cfg.Profiles[profile]
+	}
+
+	if a == nil {
+		return nil, fmt.Errorf("%w for profile %q in %s", ErrNoCredentials, profile, s.path)
+	}
+
+	return &Credentials{
+		Address:             a.Address,
+		AuthType:            a.Auth,
+		AccessToken:         a.Token.Access,
+		RefreshToken:        a.Token.Refresh,
+		PersonalAccessToken: a.Token.PersonalAccess,
+		Token:               a.Token.Value,
+	}, nil
+}
+
+// Profiles returns the sorted names of the named profiles in the config file.
+func (s *FileCredentialStore) Profiles() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cfg, err := s.readConfig()
+	if errors.Is(err, ErrNoCredentials) {
+		return []string{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.Sorted(maps.Keys(cfg.Profiles)), nil
+}
+
+// CurrentProfile returns the name of the profile selected in the
+// config file, which is empty when the default profile is used.
+func (s *FileCredentialStore) CurrentProfile() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cfg, err := s.readConfig()
+	if errors.Is(err, ErrNoCredentials) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return cfg.Profile, nil
+}
+
+// SetCurrentProfile selects the profile in the config file,
+// where the empty name selects the default profile.
+func (s *FileCredentialStore) SetCurrentProfile(profile string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.update(func(root *yaml.Node) {
+		yamlSetOptional(root, "profile", profile)
+	})
+}
+
+// readConfig reads the parts of the config file holding credentials.
+func (s *FileCredentialStore) readConfig() (*cliConfig, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w in %s", ErrNoCredentials, s.path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", s.path, err)
+	}
+
+	cfg := new(cliConfig)
+
+	err = yaml.Unmarshal(data, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", s.path, err)
+	}
+
+	return cfg, nil
+}
+
+// Save replaces the credentials for the profile in the config file,
+// creating the file when it does not exist. The file is replaced
+// atomically and is only readable and writable by the user.
+func (s *FileCredentialStore) Save(profile string, creds *Credentials) error {
+	if creds == nil {
+		return fmt.Errorf("no credentials provided")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.update(func(root *yaml.Node) {
+		a := yamlMapping(root, "api")
+		if len(profile) > 0 {
+			a = yamlMapping(yamlMapping(root, "profiles"), profile)
+		}
+
+		yamlSet(a, "addr", creds.Address)
+		yamlSetOptional(a, "auth", creds.AuthType)
+
+		token := yamlMapping(a, "token")
+
+		yamlSet(token, "access", creds.AccessToken)
+		yamlSet(token, "refresh", creds.RefreshToken)
+		yamlSetOptional(token, "personal_access", creds.PersonalAccessToken)
+		yamlSetOptional(token, "value", creds.Token)
+	})
+}
+
+// update applies the changes to the root of the config file, preserving
+// the rest of the config, and atomically replaces the file.
+func (s *FileCredentialStore) update(fn func(*yaml.Node)) error {
+	doc := new(yaml.Node)
+
+	data, err := os.ReadFile(s.path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("unable to read %s: %w", s.path, err)
+	}
+
+	if len(data) > 0 {
+		err = yaml.Unmarshal(data, doc)
+		if err != nil {
+			return fmt.Errorf("unable to parse %s: %w", s.path, err)
+		}
+	}
+
+	// start a new document for an empty config
+	if len(doc.Content) == 0 {
+		doc = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("unable to update %s: config is not a mapping", s.path)
+	}
+
+	fn(root)
+
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.path, out, 0o600)
+}
+
+// yamlMapping returns the mapping for the key in the mapping,
+// replacing the value for the key when it is not a mapping.
+func yamlMapping(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			v := m.Content[i+1]
+
+			if v.Kind != yaml.MappingNode {
+				*v = yaml.Node{Kind: yaml.MappingNode}
+			}
+
+			return v
+		}
+	}
+
+	v := &yaml.Node{Kind: yaml.MappingNode}
+
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, v)
+
+	return v
+}
+
+// yamlSet sets the key in the mapping to the string value.
+func yamlSet(m *yaml.Node, key, value string) {
+	v := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i+1] = v
+
+			return
+		}
+	}
+
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, v)
+}
+
+// yamlSetOptional sets the key in the mapping to the string
+// value, removing the key when the value is empty.
+func yamlSetOptional(m *yaml.Node, key, value string) {
+	if len(value) > 0 {
+		yamlSet(m, key, value)
+
+		return
+	}
+
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content = slices.Delete(m.Content, i, i+2)
+
+			return
+		}
+	}
+}
+
+// writeFileAtomic writes the data to a temporary file next
+// to the path and renames it over the path, so readers
+// never see a partially written file.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+
+	err := os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return fmt.Errorf("unable to create %s: %w", dir, err)
+	}
+
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary file in %s: %w", dir, err)
+	}
+
+	// remove the temporary file unless it was renamed
+	defer os.Remove(f.Name())
+
+	err = f.Chmod(perm)
+	if err == nil {
+		_, err = f.Write(data)
+	}
+
+	if err == nil {
+		err = f.Sync()
+	}
+
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		return fmt.Errorf("unable to write %s: %w", path, err)
+	}
+
+	err = os.Rename(f.Name(), path)
+	if err != nil {
+		return fmt.Errorf("unable to write %s: %w", path, err)
+	}
+
+	return nil
+}
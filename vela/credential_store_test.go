@@ -0,0 +1,168 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/go-vela/server/mock/server"
+)
+
+func TestFileCredentialStore_SaveLoad(t *testing.T) {
+	// setup types
+	path := filepath.Join(t.TempDir(), ".vela", "config.yml")
+
+	s, err := NewFileCredentialStore(path)
+	if err != nil {
+		t.Fatalf("NewFileCredentialStore returned err: %v", err)
+	}
+
+	prod := &Credentials{Address: "https://vela.example.com", AccessToken: "access", RefreshToken: "refresh"}
+	staging := &Credentials{Address: "https://vela-staging.example.com", AccessToken: "staging"}
+
+	// run test
+	err = s.Save("", prod)
+	if err != nil {
+		t.Fatalf("Save returned err: %v", err)
+	}
+
+	err = s.Save("staging", staging)
+	if err != nil {
+		t.Fatalf("Save returned err: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("unable to stat config: %v", err)
+	}
+
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Save wrote config with mode %v, want 0600", info.Mode().Perm())
+	}
+
+	got, err := s.Load("")
+	if err != nil || *got != *prod {
+		t.Errorf("Load is %v (err %v), want %v", got, err, prod)
+	}
+
+	got, err = s.Load("staging")
+	if err != nil || *got != *staging {
+		t.Errorf("Load is %v (err %v), want %v", got, err, staging)
+	}
+
+	_, err = s.Load("dev")
+	if !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("Load should have returned ErrNoCredentials, got %v", err)
+	}
+
+	// only the config file is left in the directory
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Save left %d files in the directory, want 1", len(entries))
+	}
+}
+
+func TestFileCredentialStore_Save_Preserve(t *testing.T) {
+	// setup types
+	path := filepath.Join(t.TempDir(), "config.yml")
+
+	cfg := `api:
+  addr: https://vela.example.com
+  token:
+    access: old
+    refresh: old
+  version: v1
+log:
+  level: info
+output: json
+`
+
+	err := os.WriteFile(path, []byte(cfg), 0o600)
+	if err != nil {
+		t.Fatalf("unable to write config: %v", err)
+	}
+
+	s, _ := NewFileCredentialStore(path)
+
+	// run test
+	err = s.Save("", &Credentials{Address: "https://vela.example.com", AccessToken: "new", RefreshToken: "new"})
+	if err != nil {
+		t.Fatalf("Save returned err: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+
+	for _, want := range []string{"version: v1", "level: info", "output: json", "access: new", "refresh: new"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Save config is missing %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestFileCredentialStore_Load_Missing(t *testing.T) {
+	s, _ := NewFileCredentialStore(filepath.Join(t.TempDir(), "config.yml"))
+
+	// run test
+	_, err := s.Load("")
+	if !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("Load should have returned ErrNoCredentials, got %v", err)
+	}
+}
+
+func TestDefaultCredentialPath(t *testing.T) {
+	t.Setenv("VELA_CONFIG", "/tmp/vela.yml")
+
+	// run test
+	got, err := DefaultCredentialPath()
+	if err != nil || got != "/tmp/vela.yml" {
+		t.Errorf("DefaultCredentialPath is %s (err %v), want /tmp/vela.yml", got, err)
+	}
+}
+
+func TestAuthentication_SetCredentialStore(t *testing.T) {
+	// setup context
+	gin.SetMode(gin.TestMode)
+
+	s := httptest.NewServer(server.FakeHandler())
+	defer s.Close()
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	store, _ := NewFileCredentialStore(filepath.Join(t.TempDir(), "config.yml"))
+
+	c.Authentication.SetCredentialStore(store, "local")
+
+	// run test
+	at, rt, _, err := c.Authentication.ExchangeTokens(t.Context(), &OAuthExchangeOptions{Code: "42", State: "411"})
+	if err != nil {
+		t.Fatalf("ExchangeTokens returned err: %v", err)
+	}
+
+	got, err := store.Load("local")
+	if err != nil {
+		t.Fatalf("Load returned err: %v", err)
+	}
+
+	want := Credentials{Address: s.URL, AccessToken: at, RefreshToken: rt}
+
+	if *got != want {
+		t.Errorf("ExchangeTokens saved %v, want %v", got, want)
+	}
+
+	_, err = c.Authentication.RefreshAccessToken(t.Context(), rt)
+	if err != nil {
+		t.Fatalf("RefreshAccessToken returned err: %v", err)
+	}
+
+	got, _ = store.Load("local")
+	if got.AccessToken != *c.Authentication.accessToken {
+		t.Errorf("RefreshAccessToken should have saved the new access token")
+	}
+}
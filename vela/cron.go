@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/adhocore/gronx"
+)
+
+// Cron represents a parsed cron expression as accepted
+// for the entry of a schedule. Entries are parsed with
+// gronx, the same library the server validates schedules
+// with, so the accepted syntax matches the server: the
+// five standard fields with names, lists, ranges and
+// steps, the L, W, # and ? modifiers for the day fields,
+// the optional seconds and year fields and the macros
+// such as @hourly and @daily. Schedules are evaluated
+// in UTC.
+type Cron struct {
+	entry string
+}
+
+// ParseCron parses the provided cron expression and
+// returns an error describing any invalid field.
+func ParseCron(entry string) (*Cron, error) {
+	expr := strings.TrimSpace(entry)
+
+	if len(expr) == 0 {
+		return nil, fmt.Errorf("cron entry must not be empty")
+	}
+
+	segments, err := gronx.Segments(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron entry %q: %w", entry, err)
+	}
+
+	if !gronx.IsValid(expr) {
+		// find the segment rejected by the parser to describe it
+		checker := new(gronx.SegmentChecker)
+		checker.SetRef(time.Now().UTC())
+
+		for pos, segment := range segments {
+			_, err := checker.CheckDue(segment, pos)
+			if err != nil {
+				return nil, fmt.Errorf("invalid cron entry %q: %w", entry, err)
+			}
+		}
+
+		return nil, fmt.Errorf("invalid cron entry %q", entry)
+	}
+
+	return &Cron{entry: entry}, nil
+}
+
+// String returns the cron expression as provided.
+func (c *Cron) String() string {
+	return c.entry
+}
+
+// Next returns the first time after t the cron expression
+// matches. The zero time is returned when no time matches,
+// e.g. for February 30th.
+func (c *Cron) Next(t time.Time) time.Time {
+	next, err := gronx.NextTickAfter(c.entry, t.UTC(), false)
+	if err != nil {
+		return time.Time{}
+	}
+
+	// the search gives up on expressions that never match,
+	// returning the time it stopped at instead of an error
+	due, err := gronx.New().IsDue(c.entry, next)
+	if err != nil || !due {
+		return time.Time{}
+	}
+
+	return next
+}
+
+// NextN returns the next n times after t the cron
+// expression matches.
+func (c *Cron) NextN(t time.Time, n int) []time.Time {
+	var runs []time.Time
+
+	for range n {
+		t = c.Next(t)
+		if t.IsZero() {
+			break
+		}
+
+		runs = append(runs, t)
+	}
+
+	return runs
+}
@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCron_ParseCron(t *testing.T) {
+	// run tests
+	tests := []struct {
+		entry   string
+		wantErr bool
+	}{
+		{entry: "0 0 * * *"},
+		{entry: "*/15 9-17 * * mon-fri"},
+		{entry: "0,30 1 1,15 JAN-JUN 7"},
+		{entry: "@weekly"},
+		{entry: "5/20 * * * *"},
+		{entry: "0 0 L * *"},
+		{entry: "0 0 15W * ?"},
+		{entry: "0 0 ? * 5L"},
+		{entry: "0 9 * * 1#2"},
+		{entry: "@15minutes"},
+		{entry: "", wantErr: true},
+		{entry: "* * * *", wantErr: true},
+		{entry: "60 * * * *", wantErr: true},
+		{entry: "* 24 * * *", wantErr: true},
+		{entry: "* * 0 * *", wantErr: true},
+		{entry: "* * * 13 *", wantErr: true},
+		{entry: "* * * * 8", wantErr: true},
+		{entry: "*/0 * * * *", wantErr: true},
+		{entry: "10-5 * * * *", wantErr: true},
+		{entry: "foo * * * *", wantErr: true},
+		{entry: "@fortnightly", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.entry, func(t *testing.T) {
+			_, err := ParseCron(tt.entry)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseCron returned err %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCron_NextN(t *testing.T) {
+	// setup types
+	// Wednesday, January 1st 2025
+	now := time.Date(2025, time.January, 1, 10, 7, 30, 0, time.UTC)
+
+	// run tests
+	tests := []struct {
+		entry string
+		want  []time.Time
+	}{
+		{
+			entry: "*/15 * * * *",
+			want: []time.Time{
+				time.Date(2025, time.January, 1, 10, 15, 0, 0, time.UTC),
+				time.Date(2025, time.January, 1, 10, 30, 0, 0, time.UTC),
+				time.Date(2025, time.January, 1, 10, 45, 0, 0, time.UTC),
+			},
+		},
+		{
+			entry: "@daily",
+			want: []time.Time{
+				time.Date(2025, time.January, 2, 0, 0, 0, 0, time.UTC),
+				time.Date(2025, time.January, 3, 0, 0, 0, 0, time.UTC),
+				time.Date(2025, time.January, 4, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			entry: "30 2 * * 0",
+			want: []time.Time{
+				time.Date(2025, time.January, 5, 2, 30, 0, 0, time.UTC),
+				time.Date(2025, time.January, 12, 2, 30, 0, 0, time.UTC),
+				time.Date(2025, time.January, 19, 2, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			// restricting both day fields matches either
+			entry: "0 0 15 * fri",
+			want: []time.Time{
+				time.Date(2025, time.January, 3, 0, 0, 0, 0, time.UTC),
+				time.Date(2025, time.January, 10, 0, 0, 0, 0, time.UTC),
+				time.Date(2025, time.January, 15, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			entry: "0 0 L * *",
+			want: []time.Time{
+				time.Date(2025, time.January, 31, 0, 0, 0, 0, time.UTC),
+				time.Date(2025, time.February, 28, 0, 0, 0, 0, time.UTC),
+				time.Date(2025, time.March, 31, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			// the weekday nearest the 15th
+			entry: "0 0 15W * ?",
+			want: []time.Time{
+				time.Date(2025, time.January, 15, 0, 0, 0, 0, time.UTC),
+				time.Date(2025, time.February, 14, 0, 0, 0, 0, time.UTC),
+				time.Date(2025, time.March, 14, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			// the last friday of the month
+			entry: "0 0 ? * 5L",
+			want: []time.Time{
+				time.Date(2025, time.January, 31, 0, 0, 0, 0, time.UTC),
+				time.Date(2025, time.February, 28, 0, 0, 0, 0, time.UTC),
+				time.Date(2025, time.March, 28, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			// the second monday of the month
+			entry: "0 9 * * 1#2",
+			want: []time.Time{
+				time.Date(2025, time.January, 13, 9, 0, 0, 0, time.UTC),
+				time.Date(2025, time.February, 10, 9, 0, 0, 0, time.UTC),
+				time.Date(2025, time.March, 10, 9, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			entry: "0 12 29 feb *",
+			want: []time.Time{
+				time.Date(2028, time.February, 29, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			entry: "0 0 30 2 *",
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.entry, func(t *testing.T) {
+			c, err := ParseCron(tt.entry)
+			if err != nil {
+				t.Fatalf("ParseCron returned err: %v", err)
+			}
+
+			// request an extra run for expressions that never match
+			got := c.NextN(now, max(len(tt.want), 1))
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NextN is %v, want %v", got, tt.want)
+			}
+
+			if c.String() != tt.entry {
+				t.Errorf("String is %v, want %v", c.String(), tt.entry)
+			}
+		})
+	}
+}
@@ -5,4 +5,16 @@
 // Usage:
 //
 //	import "github.com/go-vela/sdk-go/vela"
+//
+// Identifiers use the same type across every service: build, hook and
+// deployment numbers are int64, and step and service numbers are int32.
+// The scopes returned by Client.ForRepo, Client.ForOrg and Client.ForBuild
+// fill in the org, repo and build for each request:
+//
+//	build := client.ForBuild("github", "octocat", 1)
+//
+//	step, _, err := build.Steps().Get(ctx, 2)
+//
+// Service methods taking int identifiers are deprecated
+// in favor of the scopes, which take the types above.
 package vela
@@ -0,0 +1,184 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IDTokenProviderOptions specifies the optional parameters
+// to the NewIDTokenProvider function.
+type IDTokenProviderOptions struct {
+	// Request token used to exchange for ID tokens, such as the
+	// one provided to steps in VELA_ID_TOKEN_REQUEST_TOKEN. When
+	// empty, one is requested with the build token for the client.
+	RequestToken string
+
+	// Parameters used when requesting a request token.
+	Request *RequestTokenOptions
+
+	// Time before the expiration of an ID token
+	// after which a new one is requested.
+	//
+	// Default: 1m
+	RefreshBefore time.Duration
+
+	// Skip verifying the ID tokens against the keys for the server.
+	SkipVerify bool
+}
+
+// IDTokenProvider exchanges request tokens for ID tokens for
+// a build, caching the ID tokens per audience until they are
+// close to expiring.
+type IDTokenProvider struct {
+	client *Client
+	org    string
+	repo   string
+	build  int64
+	opt    IDTokenProviderOptions
+
+	verifier *TokenVerifier
+
+	mu           sync.Mutex
+	requestToken string
+	tokens       map[string]*idToken
+}
+
+// idToken represents an ID token cached by the provider.
+type idToken struct {
+	token   string
+	expires time.Time
+}
+
+// NewIDTokenProvider returns a provider of ID tokens for the build.
+// The client must use build token authentication unless a request
+// token is provided.
+func NewIDTokenProvider(c *Client, org, repo string, build int64, opt *IDTokenProviderOptions) (*IDTokenProvider, error) {
+	if c == nil {
+		return nil, fmt.Errorf("no client provided")
+	}
+
+	if len(org) == 0 || len(repo) == 0 || build <= 0 {
+		return nil, fmt.Errorf("no build provided")
+	}
+
+	if opt == nil {
+		opt = new(IDTokenProviderOptions)
+	}
+
+	p := &IDTokenProvider{
+		client:       c,
+		org:          org,
+		repo:         repo,
+		build:        build,
+		opt:          *opt,
+		requestToken: opt.RequestToken,
+		tokens:       make(map[string]*idToken),
+	}
+
+	if p.opt.RefreshBefore <= 0 {
+		p.opt.RefreshBefore = time.Minute
+	}
+
+	// the audiences are checked for each token instead
+	v, err := NewTokenVerifier(c, &TokenVerifierOptions{
+		SkipAudienceCheck: true,
+		Repo:              fmt.Sprintf("%s/%s", org, repo),
+		Build:             build,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p.verifier = v
+
+	return p, nil
+}
+
+// Token returns an ID token for the audiences, reusing
+// the cached token until it is close to expiring.
+func (p *IDTokenProvider) Token(ctx context.Context, audience ...string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// the order of the audiences does not change the token
+	audience = slices.Compact(slices.Sorted(slices.Values(audience)))
+	key := strings.Join(audience, ",")
+
+	if t, ok := p.tokens[key]; ok && time.Until(t.expires) > p.opt.RefreshBefore {
+		return t.token, nil
+	}
+
+	reqToken, err := p.getRequestToken(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// the request token replaces the authentication for the
+	// exchange, so a separate client is used for the request
+	ex, err := NewClient(p.client.baseURL.String(), "", p.client.client)
+	if err != nil {
+		return "", err
+	}
+
+	ex.UserAgent = p.client.UserAgent
+	ex.Authentication.SetTokenAuth(reqToken)
+
+	tkn, _, err := ex.Build.getIDToken(ctx, p.org, p.repo, p.build, &IDTokenOptions{Audience: audience})
+	if err != nil {
+		return "", fmt.Errorf("unable to get ID token for %s/%s/%d: %w", p.org, p.repo, p.build, err)
+	}
+
+	t := &idToken{token: tkn.GetToken()}
+
+	if p.opt.SkipVerify {
+		t.expires, _ = tokenExpiration(t.token)
+	} else {
+		claims, err := p.verifier.verify(ctx, t.token, audience)
+		if err != nil {
+			return "", fmt.Errorf("unable to verify ID token: %w", err)
+		}
+
+		t.expires = claims.ExpiresAt.Time
+	}
+
+	p.tokens[key] = t
+
+	return t.token, nil
+}
+
+// TokenSource returns a function returning ID tokens for the audiences,
+// suitable for the credential chains of cloud provider SDKs.
+func (p *IDTokenProvider) TokenSource(audience ...string) func(context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		return p.Token(ctx, audience...)
+	}
+}
+
+// getRequestToken returns the request token, requesting a
+// new one when none was provided or the current one expired.
+func (p *IDTokenProvider) getRequestToken(ctx context.Context) (string, error) {
+	if len(p.requestToken) > 0 && !p.client.Authentication.isTokenExpired(p.requestToken) {
+		return p.requestToken, nil
+	}
+
+	// a provided request token can only be replaced
+	// when the client is able to request a new one
+	if len(p.opt.RequestToken) > 0 && !p.client.Authentication.HasBuildTokenAuth() {
+		return "", fmt.Errorf("request token for %s/%s/%d has expired", p.org, p.repo, p.build)
+	}
+
+	tkn, _, err := p.client.Build.GetIDRequestToken(ctx, p.org, p.repo, p.build, p.opt.Request)
+	if err != nil {
+		return "", fmt.Errorf("unable to get ID request token for %s/%s/%d: %w", p.org, p.repo, p.build, err)
+	}
+
+	p.requestToken = tkn.GetToken()
+
+	return p.requestToken, nil
+}
@@ -0,0 +1,236 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+
+	api "github.com/go-vela/server/api/types"
+)
+
+// idTokenServer is a minimal stand-in for the Vela
+// API exchanging request tokens for ID tokens.
+type idTokenServer struct {
+	sync.Mutex
+
+	key  *rsa.PrivateKey
+	kid  string
+	repo string
+
+	// keys published alongside the signing key
+	extra []map[string]string
+
+	requests  int
+	exchanges int
+	jwks      int
+	configs   int
+}
+
+func newIDTokenServer(t *testing.T) *idTokenServer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+
+	return &idTokenServer{key: key, kid: "key_1", repo: "github/octocat"}
+}
+
+// sign returns the claims signed by the current key for the server.
+func (is *idTokenServer) sign(t *testing.T, claims api.OpenIDClaims) string {
+	t.Helper()
+
+	claims.Issuer = "https://vela.com/_services/token"
+
+	tkn := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	tkn.Header["kid"] = is.kid
+
+	signed, err := tkn.SignedString(is.key)
+	if err != nil {
+		t.Errorf("unable to sign token: %v", err)
+	}
+
+	return signed
+}
+
+// rotate replaces the key for the server with a new key.
+func (is *idTokenServer) rotate(t *testing.T) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+
+	is.key = key
+	is.kid += "_rotated"
+}
+
+func (is *idTokenServer) routes(t *testing.T, mux *http.ServeMux) {
+	t.Helper()
+
+	reqToken := makeSampleToken(jwt.MapClaims{"exp": float64(time.Now().Add(time.Hour).Unix())})
+
+	mux.HandleFunc("GET /api/v1/repos/{org}/{repo}/builds/{build}/id_request_token", func(w http.ResponseWriter, _ *http.Request) {
+		is.Lock()
+		defer is.Unlock()
+
+		is.requests++
+
+		_ = json.NewEncoder(w).Encode(api.Token{Token: new(reqToken)})
+	})
+
+	mux.HandleFunc("GET /api/v1/repos/{org}/{repo}/builds/{build}/id_token", func(w http.ResponseWriter, r *http.Request) {
+		is.Lock()
+		defer is.Unlock()
+
+		if r.Header.Get("Authorization") != "Bearer "+reqToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"unauthorized"}`))
+
+			return
+		}
+
+		is.exchanges++
+
+		claims := api.OpenIDClaims{
+			Repo:        is.repo,
+			BuildNumber: r.PathValue("build"),
+			RegisteredClaims: jwt.RegisteredClaims{
+				Audience:  r.URL.Query()["audience"],
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+		}
+
+		_ = json.NewEncoder(w).Encode(api.Token{Token: new(is.sign(t, claims))})
+	})
+
+	mux.HandleFunc("POST /api/v1/admin/rotate_oidc_keys", func(w http.ResponseWriter, _ *http.Request) {
+		is.Lock()
+		defer is.Unlock()
+
+		is.rotate(t)
+
+		_, _ = w.Write([]byte(`"keys rotated successfully"`))
+	})
+
+	mux.HandleFunc("GET /_services/token/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		is.Lock()
+		defer is.Unlock()
+
+		is.configs++
+
+		_ = json.NewEncoder(w).Encode(api.OpenIDConfig{Issuer: "https://vela.com/_services/token"})
+	})
+
+	mux.HandleFunc("GET /_services/token/.well-known/jwks", func(w http.ResponseWriter, _ *http.Request) {
+		is.Lock()
+		defer is.Unlock()
+
+		is.jwks++
+
+		keys := []map[string]string{{
+			"kty": "RSA",
+			"kid": is.kid,
+			"n":   base64.RawURLEncoding.EncodeToString(is.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(is.key.E)).Bytes()),
+		}}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": append(keys, is.extra...)})
+	})
+}
+
+func TestIDTokenProvider_Token(t *testing.T) {
+	is := newIDTokenServer(t)
+
+	s := fakeServer(t, func(mux *http.ServeMux) { is.routes(t, mux) })
+
+	c, _ := NewClient(s.URL, "", nil)
+	c.Authentication.SetBuildTokenAuth("build", "scm", 0, "github/octocat", 1)
+
+	p, err := NewIDTokenProvider(c, "github", "octocat", 1, nil)
+	if err != nil {
+		t.Fatalf("NewIDTokenProvider returned err: %v", err)
+	}
+
+	// run test
+	got, err := p.Token(t.Context(), "sts.amazonaws.com", "vault")
+	if err != nil {
+		t.Fatalf("Token returned err: %v", err)
+	}
+
+	// the audiences in a different order use the cached token
+	again, err := p.TokenSource("vault", "sts.amazonaws.com")(t.Context())
+	if err != nil {
+		t.Errorf("TokenSource returned err: %v", err)
+	}
+
+	if again != got {
+		t.Errorf("TokenSource should have returned the cached token")
+	}
+
+	_, err = p.Token(t.Context(), "vault")
+	if err != nil {
+		t.Errorf("Token returned err: %v", err)
+	}
+
+	if is.requests != 1 || is.exchanges != 2 || is.jwks != 1 {
+		t.Errorf("Token made %d requests, %d exchanges and %d key fetches, want 1, 2 and 1", is.requests, is.exchanges, is.jwks)
+	}
+}
+
+func TestIDTokenProvider_Token_RequestToken(t *testing.T) {
+	is := newIDTokenServer(t)
+
+	s := fakeServer(t, func(mux *http.ServeMux) { is.routes(t, mux) })
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	// expired request token without a way to request a new one
+	expired := makeSampleToken(jwt.MapClaims{"exp": float64(time.Now().Add(-time.Hour).Unix())})
+
+	p, _ := NewIDTokenProvider(c, "github", "octocat", 1, &IDTokenProviderOptions{RequestToken: expired})
+
+	// run test
+	_, err := p.Token(t.Context(), "vault")
+	if err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("Token should have returned err for expired request token, got %v", err)
+	}
+}
+
+func TestIDTokenProvider_Token_Verify(t *testing.T) {
+	is := newIDTokenServer(t)
+	is.repo = "github/hello-world"
+
+	s := fakeServer(t, func(mux *http.ServeMux) { is.routes(t, mux) })
+
+	c, _ := NewClient(s.URL, "", nil)
+	c.Authentication.SetBuildTokenAuth("build", "scm", 0, "github/octocat", 1)
+
+	p, _ := NewIDTokenProvider(c, "github", "octocat", 1, nil)
+
+	// run test
+	_, err := p.Token(t.Context(), "vault")
+	if err == nil {
+		t.Errorf("Token should have returned err for mismatched repo")
+	}
+
+	p, _ = NewIDTokenProvider(c, "github", "octocat", 1, &IDTokenProviderOptions{SkipVerify: true})
+
+	_, err = p.Token(t.Context(), "vault")
+	if err != nil {
+		t.Errorf("Token returned err: %v", err)
+	}
+}
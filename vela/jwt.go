@@ -3,46 +3,214 @@
 package vela
 
 import (
+	"fmt"
+	"strconv"
 	"time"
 
 	jwt "github.com/golang-jwt/jwt/v5"
+
+	"github.com/go-vela/server/constants"
 )
 
-// IsTokenExpired will parse the expiration of the the given
-// token and return a boolean depending on whether the is
-// expired given the delta.
-func IsTokenExpired(token string) bool {
-	minTimeLeft := 10 * time.Second
+// defaultTokenExpirySkew is the default time before the
+// expiration of a token after which it is treated as expired,
+// allowing for clock skew and the time to send a request.
+const defaultTokenExpirySkew = 10 * time.Second
+
+// TokenKind represents the kind of token issued by the server.
+type TokenKind string
+
+const (
+	// TokenKindUnknown defines the kind for tokens
+	// without a type recognized by the SDK.
+	TokenKindUnknown TokenKind = ""
+
+	// TokenKindUserAccess defines the kind for user access tokens.
+	TokenKindUserAccess TokenKind = constants.UserAccessTokenType
+
+	// TokenKindUserRefresh defines the kind for user refresh tokens.
+	TokenKindUserRefresh TokenKind = constants.UserRefreshTokenType
+
+	// TokenKindBuild defines the kind for build tokens
+	// used by workers to execute a build.
+	TokenKindBuild TokenKind = constants.WorkerBuildTokenType
+
+	// TokenKindWorkerAuth defines the kind for worker auth tokens.
+	TokenKindWorkerAuth TokenKind = constants.WorkerAuthTokenType
+
+	// TokenKindWorkerRegister defines the kind for worker registration tokens.
+	TokenKindWorkerRegister TokenKind = constants.WorkerRegisterTokenType
+
+	// TokenKindIDRequest defines the kind for ID request tokens.
+	TokenKindIDRequest TokenKind = constants.IDRequestTokenType
+
+	// TokenKindID defines the kind for ID tokens.
+	TokenKindID TokenKind = constants.IDTokenType
+)
+
+// TokenClaims represents the claims of a token issued by the server.
+type TokenClaims struct {
+	Kind TokenKind
+
+	// Registered claims for the token.
+	Subject   string
+	Issuer    string
+	Audience  []string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+
+	// Claims for the build the token was issued for.
+	Repo        string
+	BuildID     int64
+	BuildNumber int64
 
-	// if the token is empty, we treat it as expired
+	// Claims for the user the token was issued for.
+	IsActive bool
+	IsAdmin  bool
+
+	// Claims for the request for ID tokens.
+	Actor    string
+	Event    string
+	Ref      string
+	Image    string
+	Request  string
+	Commands bool
+
+	// All claims in the token.
+	Raw jwt.MapClaims
+}
+
+// TimeLeft returns the time until the token expires,
+// which is zero when the token has no expiration.
+func (c *TokenClaims) TimeLeft() time.Duration {
+	if c.ExpiresAt.IsZero() {
+		return 0
+	}
+
+	return time.Until(c.ExpiresAt)
+}
+
+// IsExpired returns whether the token expires within the skew.
+// Tokens without an expiration are treated as expired.
+func (c *TokenClaims) IsExpired(skew time.Duration) bool {
+	return c.ExpiresAt.IsZero() || c.TimeLeft() <= skew
+}
+
+// ParseTokenClaims parses the claims of the given token without
+// verifying it - use a TokenVerifier to verify ID tokens.
+func ParseTokenClaims(token string) (*TokenClaims, error) {
 	if len(token) == 0 {
-		return true
+		return nil, fmt.Errorf("no token provided")
 	}
 
-	// parse the token, we just want to check expiration -
-	// the server will handle verification
 	t, _, err := new(jwt.Parser).ParseUnverified(token, jwt.MapClaims{})
 	if err != nil {
-		return true
+		return nil, fmt.Errorf("unable to parse token: %w", err)
 	}
 
-	// get the claims
-	c, ok := t.Claims.(jwt.MapClaims)
+	m, ok := t.Claims.(jwt.MapClaims)
 	if !ok {
-		return true
+		return nil, fmt.Errorf("unable to parse token claims")
+	}
+
+	c := &TokenClaims{Raw: m}
+
+	c.Subject, _ = m.GetSubject()
+	c.Issuer, _ = m.GetIssuer()
+	c.Audience, _ = m.GetAudience()
+
+	if exp, err := m.GetExpirationTime(); err == nil && exp != nil {
+		c.ExpiresAt = exp.Time
+	}
+
+	if iat, err := m.GetIssuedAt(); err == nil && iat != nil {
+		c.IssuedAt = iat.Time
 	}
 
-	// if there is no expiration set, it's expired
-	if _, ok := c["exp"]; !ok {
+	switch kind := TokenKind(claimString(m, "token_type")); kind {
+	case TokenKindUserAccess, TokenKindUserRefresh, TokenKindBuild, TokenKindWorkerAuth,
+		TokenKindWorkerRegister, TokenKindIDRequest, TokenKindID:
+		c.Kind = kind
+	}
+
+	c.Repo = claimString(m, "repo")
+	c.BuildID = claimInt(m, "build_id")
+	c.BuildNumber = claimInt(m, "build_number")
+	c.IsActive = claimBool(m, "is_active")
+	c.IsAdmin = claimBool(m, "is_admin")
+	c.Actor = claimString(m, "actor")
+	c.Event = claimString(m, "event")
+	c.Ref = claimString(m, "ref")
+	c.Image = claimString(m, "image")
+	c.Request = claimString(m, "request")
+	c.Commands = claimBool(m, "commands")
+
+	return c, nil
+}
+
+// IsTokenExpired will parse the expiration of the the given
+// token and return a boolean depending on whether the is
+// expired given the default skew of 10s.
+func IsTokenExpired(token string) bool {
+	return isTokenExpired(token, defaultTokenExpirySkew)
+}
+
+// isTokenExpired returns whether the token is expired given the skew.
+func isTokenExpired(token string, skew time.Duration) bool {
+	// parse the token, we just want to check expiration -
+	// the server will handle verification
+	c, err := ParseTokenClaims(token)
+	if err != nil {
 		return true
 	}
 
-	// check the expiration
-	expiration := time.Unix(int64(c["exp"].(float64)), 0)
+	return c.IsExpired(skew)
+}
+
+// tokenExpiration returns the expiration of the
+// token without verifying it, if one is set.
+func tokenExpiration(token string) (time.Time, bool) {
+	c, err := ParseTokenClaims(token)
+	if err != nil || c.ExpiresAt.IsZero() {
+		return time.Time{}, false
+	}
+
+	return c.ExpiresAt, true
+}
+
+// claimString returns the claim as a string.
+func claimString(m jwt.MapClaims, name string) string {
+	s, _ := m[name].(string)
+
+	return s
+}
+
+// claimInt returns the claim as an integer. ID tokens
+// include numbers as strings, so both are accepted.
+func claimInt(m jwt.MapClaims, name string) int64 {
+	switch v := m[name].(type) {
+	case float64:
+		return int64(v)
+	case string:
+		i, _ := strconv.ParseInt(v, 10, 64)
+
+		return i
+	}
+
+	return 0
+}
+
+// claimBool returns the claim as a boolean. ID tokens
+// include booleans as strings, so both are accepted.
+func claimBool(m jwt.MapClaims, name string) bool {
+	switch v := m[name].(type) {
+	case bool:
+		return v
+	case string:
+		b, _ := strconv.ParseBool(v)
 
-	// get the difference
-	timeLeft := time.Until(expiration)
+		return b
+	}
 
-	// return whether we are within the delta time padding
-	return timeLeft.Seconds() <= minTimeLeft.Seconds()
+	return false
 }
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/go-cmp/cmp"
 )
 
 var (
@@ -89,3 +90,135 @@ func makeSampleToken(c jwt.Claims) string {
 
 	return s
 }
+
+func TestTokenExpiration(t *testing.T) {
+	// setup types
+	exp := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	// run test
+	got, ok := tokenExpiration(makeSampleToken(jwt.MapClaims{"exp": float64(exp.Unix())}))
+	if !ok || !got.Equal(exp) {
+		t.Errorf("tokenExpiration is %v, want %v", got, exp)
+	}
+
+	_, ok = tokenExpiration(makeSampleToken(jwt.MapClaims{}))
+	if ok {
+		t.Errorf("tokenExpiration should not have returned an expiration")
+	}
+
+	_, ok = tokenExpiration("symmetric-token")
+	if ok {
+		t.Errorf("tokenExpiration should not have returned an expiration")
+	}
+}
+
+func TestParseTokenClaims(t *testing.T) {
+	// setup types
+	exp := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	// setup tests
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		want   TokenClaims
+	}{
+		{
+			name:   "user access",
+			claims: jwt.MapClaims{"token_type": "UserAccess", "sub": "octocat", "is_admin": true, "is_active": true},
+			want:   TokenClaims{Kind: TokenKindUserAccess, Subject: "octocat", IsAdmin: true, IsActive: true},
+		},
+		{
+			name:   "user refresh",
+			claims: jwt.MapClaims{"token_type": "UserRefresh", "sub": "octocat"},
+			want:   TokenClaims{Kind: TokenKindUserRefresh, Subject: "octocat"},
+		},
+		{
+			name:   "build",
+			claims: jwt.MapClaims{"token_type": "WorkerBuild", "sub": "worker_1", "build_id": float64(10), "repo": "github/octocat"},
+			want:   TokenClaims{Kind: TokenKindBuild, Subject: "worker_1", BuildID: 10, Repo: "github/octocat"},
+		},
+		{
+			name:   "worker auth",
+			claims: jwt.MapClaims{"token_type": "WorkerAuth", "sub": "worker_1"},
+			want:   TokenClaims{Kind: TokenKindWorkerAuth, Subject: "worker_1"},
+		},
+		{
+			name:   "worker registration",
+			claims: jwt.MapClaims{"token_type": "WorkerRegister", "sub": "worker_1"},
+			want:   TokenClaims{Kind: TokenKindWorkerRegister, Subject: "worker_1"},
+		},
+		{
+			name:   "ID request",
+			claims: jwt.MapClaims{"token_type": "IDRequest", "build_number": float64(1), "image": "alpine", "commands": true},
+			want:   TokenClaims{Kind: TokenKindIDRequest, BuildNumber: 1, Image: "alpine", Commands: true},
+		},
+		{
+			name: "ID",
+			claims: jwt.MapClaims{
+				"token_type":   "ID",
+				"sub":          "repo:github/octocat:ref:refs/heads/main:event:push",
+				"aud":          "vault",
+				"build_number": "1",
+				"commands":     "true",
+				"event":        "push",
+				"ref":          "refs/heads/main",
+			},
+			want: TokenClaims{
+				Kind:        TokenKindID,
+				Subject:     "repo:github/octocat:ref:refs/heads/main:event:push",
+				Audience:    []string{"vault"},
+				BuildNumber: 1,
+				Commands:    true,
+				Event:       "push",
+				Ref:         "refs/heads/main",
+			},
+		},
+		{
+			name:   "unknown",
+			claims: jwt.MapClaims{"token_type": "ServerWorker"},
+			want:   TokenClaims{Kind: TokenKindUnknown},
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.claims["exp"] = float64(exp.Unix())
+
+			got, err := ParseTokenClaims(makeSampleToken(test.claims))
+			if err != nil {
+				t.Fatalf("ParseTokenClaims returned err: %v", err)
+			}
+
+			test.want.ExpiresAt = exp
+			test.want.Raw = got.Raw
+
+			if diff := cmp.Diff(&test.want, got); diff != "" {
+				t.Errorf("ParseTokenClaims() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	_, err := ParseTokenClaims("symmetric-token")
+	if err == nil {
+		t.Errorf("ParseTokenClaims should have returned err")
+	}
+}
+
+func TestTokenClaims_IsExpired(t *testing.T) {
+	// setup types
+	c := &TokenClaims{ExpiresAt: time.Now().Add(time.Minute)}
+
+	// run test
+	if c.IsExpired(10 * time.Second) {
+		t.Errorf("IsExpired should be false with 10s skew")
+	}
+
+	if !c.IsExpired(2 * time.Minute) {
+		t.Errorf("IsExpired should be true with 2m skew")
+	}
+
+	if !new(TokenClaims).IsExpired(0) {
+		t.Errorf("IsExpired should be true without an expiration")
+	}
+}
@@ -13,6 +13,38 @@ import (
 // from the server methods of the Vela API.
 type LogService service
 
+// SetMasker sets the masker applied to the data of every log uploaded
+// with the client, including the logs uploaded by a LogWriter, so
+// secret values never leave the worker. A nil masker disables it.
+func (svc *LogService) SetMasker(m *SecretMasker) {
+	svc.client.logMasker.Store(m)
+}
+
+// masked returns a copy of the log with the data masked
+// by the masker for the client, if one is set.
+func (svc *LogService) masked(l *api.Log) *api.Log {
+	m := svc.client.logMasker.Load()
+	if m == nil || l == nil {
+		return l
+	}
+
+	v := *l
+	m.MaskLog(&v)
+
+	return &v
+}
+
+// maskedData returns the data masked by the
+// masker for the client, if one is set.
+func (svc *LogService) maskedData(data []byte) []byte {
+	m := svc.client.logMasker.Load()
+	if m == nil {
+		return data
+	}
+
+	return m.Mask(data)
+}
+
 // GetService returns the provided service log.
 func (svc *LogService) GetService(ctx context.Context, org, repo string, build int64, service int32) (*api.Log, *Response, error) {
 	// set the API endpoint path we send the request to
@@ -28,12 +60,21 @@ func (svc *LogService) GetService(ctx context.Context, org, repo string, build i
 }
 
 // AddService constructs a service log with the provided details.
+//
+// Deprecated: use Client.ForBuild(org, repo, build).Logs().AddService, which takes the
+// build number as an int64 and the service number as an int32.
 func (svc *LogService) AddService(ctx context.Context, org, repo string, build, service int, l *api.Log) (*Response, error) {
+	//nolint:gosec // service numbers are int32 in the API
+	return svc.addService(ctx, org, repo, int64(build), int32(service), l)
+}
+
+// addService constructs a service log with the provided details.
+func (svc *LogService) addService(ctx context.Context, org, repo string, build int64, service int32, l *api.Log) (*Response, error) {
 	// set the API endpoint path we send the request to
 	u := fmt.Sprintf("/api/v1/repos/%s/%s/builds/%d/services/%d/logs", org, repo, build, service)
 
 	// send request using client
-	resp, err := svc.client.Call(ctx, "POST", u, l, nil)
+	resp, err := svc.client.Call(ctx, "POST", u, svc.masked(l), nil)
 
 	return resp, err
 }
@@ -44,13 +85,22 @@ func (svc *LogService) UpdateService(ctx context.Context, org, repo string, buil
 	u := fmt.Sprintf("/api/v1/repos/%s/%s/builds/%d/services/%d/logs", org, repo, build, service)
 
 	// send request using client
-	resp, err := svc.client.Call(ctx, "PUT", u, l, nil)
+	resp, err := svc.client.Call(ctx, "PUT", u, svc.masked(l), nil)
 
 	return resp, err
 }
 
 // RemoveService deletes the provided service log.
+//
+// Deprecated: use Client.ForBuild(org, repo, build).Logs().RemoveService, which takes the
+// build number as an int64 and the service number as an int32.
 func (svc *LogService) RemoveService(ctx context.Context, org, repo string, build, service int) (*string, *Response, error) {
+	//nolint:gosec // service numbers are int32 in the API
+	return svc.removeService(ctx, org, repo, int64(build), int32(service))
+}
+
+// removeService deletes the provided service log.
+func (svc *LogService) removeService(ctx context.Context, org, repo string, build int64, service int32) (*string, *Response, error) {
 	// set the API endpoint path we send the request to
 	u := fmt.Sprintf("/api/v1/repos/%s/%s/builds/%d/services/%d/logs", org, repo, build, service)
 
@@ -78,12 +128,21 @@ func (svc *LogService) GetStep(ctx context.Context, org, repo string, build int6
 }
 
 // AddStep constructs a step log with the provided details.
+//
+// Deprecated: use Client.ForBuild(org, repo, build).Logs().AddStep, which takes the
+// build number as an int64 and the step number as an int32.
 func (svc *LogService) AddStep(ctx context.Context, org, repo string, build, step int, l *api.Log) (*Response, error) {
+	//nolint:gosec // step numbers are int32 in the API
+	return svc.addStep(ctx, org, repo, int64(build), int32(step), l)
+}
+
+// addStep constructs a step log with the provided details.
+func (svc *LogService) addStep(ctx context.Context, org, repo string, build int64, step int32, l *api.Log) (*Response, error) {
 	// set the API endpoint path we send the request to
 	u := fmt.Sprintf("/api/v1/repos/%s/%s/builds/%d/steps/%d/logs", org, repo, build, step)
 
 	// send request using client
-	resp, err := svc.client.Call(ctx, "POST", u, l, nil)
+	resp, err := svc.client.Call(ctx, "POST", u, svc.masked(l), nil)
 
 	return resp, err
 }
@@ -94,13 +153,22 @@ func (svc *LogService) UpdateStep(ctx context.Context, org, repo string, build i
 	u := fmt.Sprintf("/api/v1/repos/%s/%s/builds/%d/steps/%d/logs", org, repo, build, step)
 
 	// send request using client
-	resp, err := svc.client.Call(ctx, "PUT", u, l, nil)
+	resp, err := svc.client.Call(ctx, "PUT", u, svc.masked(l), nil)
 
 	return resp, err
 }
 
 // RemoveStep deletes the provided step log.
+//
+// Deprecated: use Client.ForBuild(org, repo, build).Logs().RemoveStep, which takes the
+// build number as an int64 and the step number as an int32.
 func (svc *LogService) RemoveStep(ctx context.Context, org, repo string, build, step int) (*string, *Response, error) {
+	//nolint:gosec // step numbers are int32 in the API
+	return svc.removeStep(ctx, org, repo, int64(build), int32(step))
+}
+
+// removeStep deletes the provided step log.
+func (svc *LogService) removeStep(ctx context.Context, org, repo string, build int64, step int32) (*string, *Response, error) {
 	// set the API endpoint path we send the request to
 	u := fmt.Sprintf("/api/v1/repos/%s/%s/builds/%d/steps/%d/logs", org, repo, build, step)
 
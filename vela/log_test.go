@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -480,3 +481,129 @@ func ExampleLogService_RemoveStep() {
 
 	fmt.Printf("Received response code %d, for log %+v", resp.StatusCode, log)
 }
+
+func TestLog_SetMasker(t *testing.T) {
+	ls := new(logServer)
+
+	s := fakeServer(t, ls.routes)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	c.Log.SetMasker(NewSecretMasker("hunter2"))
+
+	b := c.ForBuild("github", "octocat", 1)
+
+	// setup tests
+	tests := []struct {
+		name   string
+		upload func(ctx context.Context, l *api.Log) error
+	}{
+		{
+			name: "AddStep",
+			upload: func(ctx context.Context, l *api.Log) error {
+				_, err := c.Log.AddStep(ctx, "github", "octocat", 1, 1, l)
+				return err
+			},
+		},
+		{
+			name: "UpdateStep",
+			upload: func(ctx context.Context, l *api.Log) error {
+				_, err := c.Log.UpdateStep(ctx, "github", "octocat", 1, 1, l)
+				return err
+			},
+		},
+		{
+			name: "AddService",
+			upload: func(ctx context.Context, l *api.Log) error {
+				_, err := c.Log.AddService(ctx, "github", "octocat", 1, 1, l)
+				return err
+			},
+		},
+		{
+			name: "UpdateService",
+			upload: func(ctx context.Context, l *api.Log) error {
+				_, err := c.Log.UpdateService(ctx, "github", "octocat", 1, 1, l)
+				return err
+			},
+		},
+		{
+			name: "BuildLogs.AddStep",
+			upload: func(ctx context.Context, l *api.Log) error {
+				_, err := b.Logs().AddStep(ctx, 1, l)
+				return err
+			},
+		},
+		{
+			name: "BuildLogs.UpdateStep",
+			upload: func(ctx context.Context, l *api.Log) error {
+				_, err := b.Logs().UpdateStep(ctx, 1, l)
+				return err
+			},
+		},
+		{
+			name: "BuildLogs.AddService",
+			upload: func(ctx context.Context, l *api.Log) error {
+				_, err := b.Logs().AddService(ctx, 1, l)
+				return err
+			},
+		},
+		{
+			name: "BuildLogs.UpdateService",
+			upload: func(ctx context.Context, l *api.Log) error {
+				_, err := b.Logs().UpdateService(ctx, 1, l)
+				return err
+			},
+		},
+		{
+			name: "StepWriter",
+			upload: func(ctx context.Context, l *api.Log) error {
+				w := b.Logs().StepWriter(ctx, 1, &LogWriterOptions{FlushInterval: -1})
+
+				_, _ = w.Write(l.GetData())
+
+				return w.Close()
+			},
+		},
+		{
+			name: "ServiceWriter",
+			upload: func(ctx context.Context, l *api.Log) error {
+				w := b.Logs().ServiceWriter(ctx, 1, &LogWriterOptions{FlushInterval: -1})
+
+				_, _ = w.Write(l.GetData())
+
+				return w.Close()
+			},
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := &api.Log{Data: new([]byte("password hunter2\n"))}
+
+			err := test.upload(t.Context(), l)
+			if err != nil {
+				t.Errorf("%s returned err: %v", test.name, err)
+			}
+
+			if got := ls.last(); !strings.HasSuffix(got, " password ***\n") {
+				t.Errorf("%s uploaded %q, want the secret masked", test.name, got)
+			}
+
+			if got := string(l.GetData()); got != "password hunter2\n" {
+				t.Errorf("%s modified the log data to %q", test.name, got)
+			}
+		})
+	}
+
+	c.Log.SetMasker(nil)
+
+	_, err := c.Log.UpdateStep(t.Context(), "github", "octocat", 1, 1, &api.Log{Data: new([]byte("hunter2"))})
+	if err != nil {
+		t.Errorf("UpdateStep returned err: %v", err)
+	}
+
+	if got := ls.last(); !strings.HasSuffix(got, " hunter2") {
+		t.Errorf("UpdateStep uploaded %q after the masker was removed", got)
+	}
+}
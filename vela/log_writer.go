@@ -0,0 +1,349 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultLogFlushSize is the default number of
+	// bytes buffered before a log is uploaded.
+	defaultLogFlushSize = 64 * 1024
+
+	// defaultLogFlushInterval is the default time
+	// buffered output waits before a log is uploaded.
+	defaultLogFlushInterval = 5 * time.Second
+
+	// defaultLogCloseTimeout is the default time
+	// allowed for the final upload of a log.
+	defaultLogCloseTimeout = 30 * time.Second
+
+	// logTruncated is appended to a log once
+	// it reaches the maximum size.
+	logTruncated = "\n[log truncated: exceeded the maximum size]\n"
+)
+
+// LogWriterOptions specifies the optional parameters
+// used to create a LogWriter.
+type LogWriterOptions struct {
+	// Number of buffered bytes that triggers an upload.
+	//
+	// Default: 64KiB
+	FlushSize int
+
+	// Time buffered output waits before an upload. A
+	// negative interval only uploads on size or Flush.
+	//
+	// Default: 5s
+	FlushInterval time.Duration
+
+	// Maximum size of the log in bytes. Output past the size
+	// is dropped and the log ends with a truncation notice.
+	//
+	// Default: no limit
+	MaxSize int
+
+	// Time allowed for the final upload when the writer
+	// is closed, even when the context was canceled.
+	//
+	// Default: 30s
+	CloseTimeout time.Duration
+
+	// Secret values masked in the log before upload.
+	Secrets []string
+
+	// Masker used to mask the log before upload,
+	// taking precedence over Secrets when provided.
+	// The masker set with LogService.SetMasker is
+	// applied as well.
+	Masker *SecretMasker
+}
+
+// LogWriter uploads the output of a step or service as it is written.
+// Output is buffered and uploaded when the buffer reaches the flush
+// size, when the flush interval elapses or when Flush or Close are
+// called. Secret values are masked before leaving the worker.
+//
+// The Vela API only supports replacing the log data, there is no way
+// to append to a log, so every upload sends the whole log written so
+// far and the writer keeps all of it in memory. Set MaxSize to bound
+// both for steps with large output.
+type LogWriter struct {
+	client *Client
+	url    string
+	opt    LogWriterOptions
+
+	// ctx is the context the writer was created with,
+	// used for the uploads triggered by Write and Close.
+	ctx context.Context
+
+	// upload serializes the uploads so every
+	// upload includes the previous uploads.
+	upload sync.Mutex
+
+	mu        sync.Mutex
+	data      []byte
+	pending   *bytes.Buffer
+	uploading int
+	masked    *MaskWriter
+	err       error
+	closed    bool
+	truncated bool
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// StepWriter returns a LogWriter uploading the log for the step.
+// The writer must be closed to upload the remaining output.
+func (svc *LogService) StepWriter(ctx context.Context, org, repo string, build int64, step int32, opt *LogWriterOptions) *LogWriter {
+	// set the API endpoint path we send the request to
+	u := fmt.Sprintf("/api/v1/repos/%s/%s/builds/%d/steps/%d/logs", org, repo, build, step)
+
+	return newLogWriter(ctx, svc.client, u, opt)
+}
+
+// ServiceWriter returns a LogWriter uploading the log for the service.
+// The writer must be closed to upload the remaining output.
+func (svc *LogService) ServiceWriter(ctx context.Context, org, repo string, build int64, service int32, opt *LogWriterOptions) *LogWriter {
+	// set the API endpoint path we send the request to
+	u := fmt.Sprintf("/api/v1/repos/%s/%s/builds/%d/services/%d/logs", org, repo, build, service)
+
+	return newLogWriter(ctx, svc.client, u, opt)
+}
+
+// newLogWriter creates a LogWriter and starts the interval flushes.
+func newLogWriter(ctx context.Context, c *Client, u string, opt *LogWriterOptions) *LogWriter {
+	if opt == nil {
+		opt = new(LogWriterOptions)
+	}
+
+	w := &LogWriter{
+		client:  c,
+		url:     u,
+		opt:     *opt,
+		ctx:     ctx,
+		done:    make(chan struct{}),
+		pending: new(bytes.Buffer),
+	}
+
+	if w.opt.Masker == nil {
+		w.opt.Masker = NewSecretMasker(w.opt.Secrets...)
+	}
+
+	// output is masked as it is written so the
+	// pending buffer never holds a secret value
+	w.masked = w.opt.Masker.Writer(w.pending)
+
+	if w.opt.FlushSize <= 0 {
+		w.opt.FlushSize = defaultLogFlushSize
+	}
+
+	if w.opt.FlushInterval == 0 {
+		w.opt.FlushInterval = defaultLogFlushInterval
+	}
+
+	if w.opt.CloseTimeout <= 0 {
+		w.opt.CloseTimeout = defaultLogCloseTimeout
+	}
+
+	if w.opt.FlushInterval > 0 {
+		w.wg.Add(1)
+
+		go w.flushEvery(ctx, w.opt.FlushInterval)
+	}
+
+	return w
+}
+
+// Write buffers the output, uploading the log once
+// the buffered output reaches the flush size using
+// the context provided when the writer was created.
+// Errors from previous uploads are returned by later
+// writes.
+func (w *LogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+
+	if w.closed {
+		w.mu.Unlock()
+
+		return 0, fmt.Errorf("log writer is closed")
+	}
+
+	// output past the maximum size is dropped
+	if !w.truncated {
+		_, _ = w.masked.Write(p)
+	}
+
+	// only output written since the current upload started counts
+	full := w.pending.Len()-w.uploading >= w.opt.FlushSize
+
+	w.mu.Unlock()
+
+	if full {
+		_ = w.flush(w.ctx, false)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return len(p), w.err
+}
+
+// Flush uploads the buffered output. The end of the output that
+// could be the start of a secret value is held back until more
+// output is written or the writer is closed.
+func (w *LogWriter) Flush(ctx context.Context) error {
+	return w.flush(ctx, false)
+}
+
+// Close stops the interval flushes and uploads the remaining output.
+// The upload is not canceled with the context provided when the writer
+// was created, since the context commonly ends with the step, but is
+// limited to the close timeout.
+func (w *LogWriter) Close() error {
+	w.mu.Lock()
+
+	if w.closed {
+		w.mu.Unlock()
+
+		return w.err
+	}
+
+	w.closed = true
+	close(w.done)
+
+	w.mu.Unlock()
+
+	w.wg.Wait()
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(w.ctx), w.opt.CloseTimeout)
+	defer cancel()
+
+	return w.flush(ctx, true)
+}
+
+// Bytes returns the masked log uploaded by the writer.
+func (w *LogWriter) Bytes() []byte {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return bytes.Clone(w.data)
+}
+
+// flushEvery uploads the buffered output on every interval
+// until the writer is closed or the context is canceled.
+func (w *LogWriter) flushEvery(ctx context.Context, interval time.Duration) {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = w.flush(ctx, false)
+		}
+	}
+}
+
+// flush uploads the log with the buffered output. The log is
+// captured under the lock and uploaded without holding it, so
+// output can be written during the upload. The output is only
+// added to the uploaded log once it is sent, so a failed upload
+// is retried on the next flush.
+func (w *LogWriter) flush(ctx context.Context, final bool) error {
+	w.upload.Lock()
+	defer w.upload.Unlock()
+
+	w.mu.Lock()
+
+	if final {
+		_ = w.masked.Flush()
+	}
+
+	n := w.pending.Len()
+
+	// output written after the log was truncated is dropped
+	if w.truncated {
+		w.pending.Reset()
+	}
+
+	if n == 0 || w.truncated {
+		w.mu.Unlock()
+
+		return nil
+	}
+
+	data := append(w.data[:len(w.data):len(w.data)], w.pending.Bytes()...)
+
+	truncate := w.opt.MaxSize > 0 && len(data) > w.opt.MaxSize
+	if truncate {
+		data = append(data[:w.opt.MaxSize], logTruncated...)
+	}
+
+	w.uploading = n
+
+	w.mu.Unlock()
+
+	// the body is closed even when the request fails
+	// before it is read to stop encoding the log
+	body := newLogBody(w.client.Log.maskedData(data))
+	defer body.Close()
+
+	_, err := w.client.Call(ctx, "PUT", w.url, body, nil)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.uploading = 0
+
+	if err != nil {
+		w.err = fmt.Errorf("unable to upload log: %w", err)
+
+		return w.err
+	}
+
+	w.data = data
+	w.truncated = truncate
+	w.err = nil
+
+	// keep the output written during the upload
+	w.pending.Next(n)
+
+	return nil
+}
+
+// newLogBody returns a JSON encoded log with the data
+// streamed directly from the provided bytes, avoiding
+// an encoded copy of the log. The body must be closed
+// to release the encoding goroutine.
+func newLogBody(data []byte) io.ReadCloser {
+	pr, pw := io.Pipe()
+
+	go func() {
+		_, _ = io.WriteString(pw, `{"data":"`)
+
+		enc := base64.NewEncoder(base64.StdEncoding, pw)
+
+		_, _ = enc.Write(data)
+		_ = enc.Close()
+
+		_, err := io.WriteString(pw, `"}`)
+
+		pw.CloseWithError(err)
+	}()
+
+	return pr
+}
@@ -0,0 +1,296 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	api "github.com/go-vela/server/api/types"
+)
+
+// logServer is a minimal stand-in for the
+// Vela API storing the uploaded logs.
+type logServer struct {
+	sync.Mutex
+
+	uploads []string
+	fail    bool
+}
+
+func (ls *logServer) routes(mux *http.ServeMux) {
+	upload := func(w http.ResponseWriter, r *http.Request) {
+		ls.Lock()
+		defer ls.Unlock()
+
+		if ls.fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"error":"boom"}`))
+
+			return
+		}
+
+		var l api.Log
+
+		err := json.NewDecoder(r.Body).Decode(&l)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid log"}`))
+
+			return
+		}
+
+		ls.uploads = append(ls.uploads, r.Method+" "+r.URL.Path+" "+string(l.GetData()))
+	}
+
+	mux.HandleFunc("POST /api/v1/repos/{org}/{repo}/builds/{build}/steps/{step}/logs", upload)
+	mux.HandleFunc("PUT /api/v1/repos/{org}/{repo}/builds/{build}/steps/{step}/logs", upload)
+	mux.HandleFunc("POST /api/v1/repos/{org}/{repo}/builds/{build}/services/{service}/logs", upload)
+	mux.HandleFunc("PUT /api/v1/repos/{org}/{repo}/builds/{build}/services/{service}/logs", upload)
+}
+
+func (ls *logServer) last() string {
+	ls.Lock()
+	defer ls.Unlock()
+
+	if len(ls.uploads) == 0 {
+		return ""
+	}
+
+	return ls.uploads[len(ls.uploads)-1]
+}
+
+func TestLog_StepWriter(t *testing.T) {
+	ls := new(logServer)
+
+	s := fakeServer(t, ls.routes)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	w := c.Log.StepWriter(t.Context(), "github", "octocat", 1, 2, &LogWriterOptions{
+		FlushSize:     16,
+		FlushInterval: -1,
+		Secrets:       []string{"hunter2"},
+	})
+
+	// run test
+	_, err := w.Write([]byte("password hun"))
+	if err != nil {
+		t.Errorf("Write returned err: %v", err)
+	}
+
+	if len(ls.uploads) != 0 {
+		t.Errorf("Write should have buffered output below the flush size")
+	}
+
+	// the secret is split across writes
+	_, err = w.Write([]byte("ter2\nnext line"))
+	if err != nil {
+		t.Errorf("Write returned err: %v", err)
+	}
+
+	err = w.Flush(t.Context())
+	if err != nil {
+		t.Errorf("Flush returned err: %v", err)
+	}
+
+	// output that could start a secret is held back
+	want := "PUT /api/v1/repos/github/octocat/builds/1/steps/2/logs password ***"
+	if got := ls.last(); got != want {
+		t.Errorf("Flush uploaded %q, want %q", got, want)
+	}
+
+	_, _ = w.Write([]byte(" done\n"))
+
+	err = w.Close()
+	if err != nil {
+		t.Errorf("Close returned err: %v", err)
+	}
+
+	want = "PUT /api/v1/repos/github/octocat/builds/1/steps/2/logs password ***\nnext line done\n"
+	if got := ls.last(); got != want {
+		t.Errorf("Close uploaded %q, want %q", got, want)
+	}
+
+	if string(w.Bytes()) != "password ***\nnext line done\n" {
+		t.Errorf("Bytes is %q", w.Bytes())
+	}
+
+	_, err = w.Write([]byte("foo"))
+	if err == nil {
+		t.Errorf("Write should have returned err after Close")
+	}
+}
+
+func TestLog_ServiceWriter_Interval(t *testing.T) {
+	ls := new(logServer)
+
+	s := fakeServer(t, ls.routes)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	w := c.Log.ServiceWriter(t.Context(), "github", "octocat", 1, 1, &LogWriterOptions{FlushInterval: 10 * time.Millisecond})
+	defer w.Close()
+
+	// run test
+	_, _ = w.Write([]byte("ready\n"))
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for ls.last() == "" && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	want := "PUT /api/v1/repos/github/octocat/builds/1/services/1/logs ready\n"
+	if got := ls.last(); got != want {
+		t.Errorf("ServiceWriter uploaded %q, want %q", got, want)
+	}
+}
+
+func TestLog_StepWriter_Error(t *testing.T) {
+	ls := &logServer{fail: true}
+
+	s := fakeServer(t, ls.routes)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	w := c.Log.StepWriter(t.Context(), "github", "octocat", 1, 1, &LogWriterOptions{FlushInterval: -1})
+
+	_, _ = w.Write([]byte("hello\n"))
+
+	// run test
+	err := w.Flush(t.Context())
+	if err == nil {
+		t.Errorf("Flush should have returned err")
+	}
+
+	// the failed output is retried on the next upload
+	ls.Lock()
+	ls.fail = false
+	ls.Unlock()
+
+	err = w.Close()
+	if err != nil {
+		t.Errorf("Close returned err: %v", err)
+	}
+
+	if got := ls.last(); got != "PUT /api/v1/repos/github/octocat/builds/1/steps/1/logs hello\n" {
+		t.Errorf("Close uploaded %q", got)
+	}
+}
+
+func TestLog_StepWriter_Canceled(t *testing.T) {
+	ls := new(logServer)
+
+	s := fakeServer(t, ls.routes)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	ctx, cancel := context.WithCancel(t.Context())
+
+	w := c.Log.StepWriter(ctx, "github", "octocat", 1, 1, &LogWriterOptions{FlushSize: 4, FlushInterval: -1})
+
+	cancel()
+
+	// run test
+	_, err := w.Write([]byte("hello\n"))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Write returned err %v, want %v", err, context.Canceled)
+	}
+
+	// the end of the log is uploaded when the step ends
+	err = w.Close()
+	if err != nil {
+		t.Errorf("Close returned err: %v", err)
+	}
+
+	if got := ls.last(); got != "PUT /api/v1/repos/github/octocat/builds/1/steps/1/logs hello\n" {
+		t.Errorf("Close uploaded %q", got)
+	}
+}
+
+func TestLog_StepWriter_MaxSize(t *testing.T) {
+	ls := new(logServer)
+
+	s := fakeServer(t, ls.routes)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	w := c.Log.StepWriter(t.Context(), "github", "octocat", 1, 1, &LogWriterOptions{FlushSize: 4, FlushInterval: -1, MaxSize: 8})
+
+	// run test
+	_, _ = w.Write([]byte("hello\n"))
+	_, _ = w.Write([]byte("world\n"))
+	_, _ = w.Write([]byte("dropped\n"))
+
+	err := w.Close()
+	if err != nil {
+		t.Errorf("Close returned err: %v", err)
+	}
+
+	want := "hello\nwo" + logTruncated
+	if string(w.Bytes()) != want {
+		t.Errorf("Bytes is %q, want %q", w.Bytes(), want)
+	}
+
+	if len(ls.uploads) != 2 {
+		t.Errorf("StepWriter uploaded the log %d times, want 2", len(ls.uploads))
+	}
+}
+
+func TestLog_StepWriter_WriteDuringUpload(t *testing.T) {
+	uploading := make(chan struct{}, 1)
+	release := make(chan struct{})
+
+	s := fakeServer(t, func(mux *http.ServeMux) {
+		mux.HandleFunc("PUT /api/v1/repos/{org}/{repo}/builds/{build}/steps/{step}/logs", func(_ http.ResponseWriter, _ *http.Request) {
+			select {
+			case uploading <- struct{}{}:
+			default:
+			}
+
+			<-release
+		})
+	})
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	w := c.Log.StepWriter(t.Context(), "github", "octocat", 1, 1, &LogWriterOptions{FlushSize: 16, FlushInterval: -1})
+
+	go func() {
+		_, _ = w.Write([]byte("flushed output!\n"))
+	}()
+
+	<-uploading
+
+	// run test
+	written := make(chan struct{})
+
+	go func() {
+		_, _ = w.Write([]byte("more"))
+
+		close(written)
+	}()
+
+	select {
+	case <-written:
+	case <-time.After(5 * time.Second):
+		t.Errorf("Write should not have waited for the upload")
+	}
+
+	close(release)
+
+	err := w.Close()
+	if err != nil {
+		t.Errorf("Close returned err: %v", err)
+	}
+
+	if string(w.Bytes()) != "flushed output!\nmore" {
+		t.Errorf("Bytes is %q", w.Bytes())
+	}
+}
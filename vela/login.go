@@ -3,7 +3,17 @@
 package vela
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // AuthorizationService handles user login actions
@@ -39,3 +49,385 @@ func (svc *AuthorizationService) GetLoginURL(opt *LoginOptions) (string, error)
 	// return the url
 	return loginURL, nil
 }
+
+// LoginInteractiveOptions specifies the optional parameters
+// to the Authorization.LoginInteractive method.
+type LoginInteractiveOptions struct {
+	// Port on localhost to listen for the callback on.
+	//
+	// Default: a random available port
+	Port int
+
+	// Function called with the URL for the user to visit, such
+	// as one opening the URL in a browser. When not provided,
+	// or when it returns an error, the URL is printed to Output
+	// instead.
+	Open func(string) error
+
+	// Writer the URL and progress messages are printed to.
+	//
+	// Default: os.Stderr
+	Output io.Writer
+}
+
+// loginCallback represents the values captured from the login callback.
+type loginCallback struct {
+	code string
+	err  error
+}
+
+// LoginInteractive runs the OAuth login flow for the CLI. It listens
+// for the callback on localhost, starts the login with the server to
+// capture the OAuth state, sends the user to the SCM to authorize Vela,
+// checks the state in the callback and exchanges the code for tokens.
+// The client is configured to use the returned access and refresh tokens.
+func (svc *AuthorizationService) LoginInteractive(ctx context.Context, opt *LoginInteractiveOptions) (string, string, error) {
+	if opt == nil {
+		opt = new(LoginInteractiveOptions)
+	}
+
+	out := opt.Output
+	if out == nil {
+		out = os.Stderr
+	}
+
+	// listen on the loopback address the server redirects to
+	l, err := new(net.ListenConfig).Listen(ctx, "tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(opt.Port)))
+	if err != nil {
+		return "", "", fmt.Errorf("unable to listen for login callback: %w", err)
+	}
+
+	port := l.Addr().(*net.TCPAddr).Port
+
+	authURL, state, err := svc.startLogin(ctx, port)
+	if err != nil {
+		l.Close()
+
+		return "", "", err
+	}
+
+	callbacks := make(chan loginCallback, 1)
+
+	srv := &http.Server{
+		Handler:           loginCallbackHandler(state, callbacks),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		_ = srv.Serve(l)
+	}()
+
+	defer srv.Close()
+
+	// print the URL when it can't be opened, the
+	// callback can still be received once visited
+	if opt.Open == nil || opt.Open(authURL.String()) != nil {
+		fmt.Fprintf(out, "Visit the following URL to log in to Vela:\n\n  %s\n\n", authURL)
+	}
+
+	var cb loginCallback
+
+	select {
+	case <-ctx.Done():
+		return "", "", ctx.Err()
+	case cb = <-callbacks:
+	}
+
+	if cb.err != nil {
+		return "", "", cb.err
+	}
+
+	at, rt, err := svc.finishLogin(ctx, cb.code, state)
+	if err != nil {
+		return "", "", err
+	}
+
+	fmt.Fprintln(out, "Login successful")
+
+	return at, rt, nil
+}
+
+// LoginHeadlessOptions specifies the optional parameters
+// to the Authorization.LoginHeadless method.
+type LoginHeadlessOptions struct {
+	// Port on localhost the browser is sent to after authorizing
+	// Vela. Nothing needs to listen on the port, the user copies
+	// the URL from the browser once the page fails to load.
+	//
+	// Default: 8400
+	Port int
+
+	// Reader the code and state are read from.
+	//
+	// Default: os.Stdin
+	Input io.Reader
+
+	// Writer the URL and instructions are printed to.
+	//
+	// Default: os.Stderr
+	Output io.Writer
+}
+
+// LoginHeadless runs the OAuth login flow for sessions without a browser
+// or a loopback port reachable by one, such as SSH and CI sessions. It
+// prints the URL for the user to visit elsewhere and reads back either
+// the URL the browser was sent to, its query string, or the code and state
+// separated by a space. The state is checked before exchanging the code for
+// tokens and the client is configured to use the returned tokens.
+func (svc *AuthorizationService) LoginHeadless(ctx context.Context, opt *LoginHeadlessOptions) (string, string, error) {
+	if opt == nil {
+		opt = new(LoginHeadlessOptions)
+	}
+
+	port := opt.Port
+	if port <= 0 {
+		port = 8400
+	}
+
+	in := opt.Input
+	if in == nil {
+		in = os.Stdin
+	}
+
+	out := opt.Output
+	if out == nil {
+		out = os.Stderr
+	}
+
+	authURL, state, err := svc.startLogin(ctx, port)
+	if err != nil {
+		return "", "", err
+	}
+
+	fmt.Fprintf(out, "Visit the following URL in a browser to log in to Vela:\n\n  %s\n\n", authURL)
+	fmt.Fprintf(out, "Once authorized, the browser is sent to http://127.0.0.1:%d, which is not expected to load.\n", port)
+
+	// read the input in the background so the
+	// login stops when the context is canceled
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	lines := make(chan string)
+
+	go func() {
+		defer close(lines)
+
+		scanner := bufio.NewScanner(in)
+
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		fmt.Fprint(out, "Paste the URL from the browser, or the code and state: ")
+
+		var line string
+
+		select {
+		case <-ctx.Done():
+			return "", "", ctx.Err()
+		case l, ok := <-lines:
+			if !ok {
+				return "", "", fmt.Errorf("no login code provided")
+			}
+
+			line = strings.TrimSpace(l)
+		}
+
+		if len(line) == 0 {
+			continue
+		}
+
+		cb, err := parseLoginCallback(line, port)
+		if err != nil {
+			fmt.Fprintf(out, "%v\n", err)
+
+			continue
+		}
+
+		if cb.State != state {
+			return "", "", fmt.Errorf("login code has unexpected OAuth state")
+		}
+
+		at, rt, err := svc.finishLogin(ctx, cb.Code, cb.State)
+		if err != nil {
+			return "", "", err
+		}
+
+		fmt.Fprintln(out, "Login successful")
+
+		return at, rt, nil
+	}
+}
+
+// startLogin starts the login with the server for the CLI
+// on the port, returning the URL for authorizing with the
+// SCM and the OAuth state generated by the server.
+func (svc *AuthorizationService) startLogin(ctx context.Context, port int) (*url.URL, string, error) {
+	loginURL, err := svc.GetLoginURL(&LoginOptions{Type: "cli", Port: strconv.Itoa(port)})
+	if err != nil {
+		return nil, "", err
+	}
+
+	authURL, err := svc.authorizeURL(ctx, loginURL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	state := authURL.Query().Get("state")
+	if len(state) == 0 {
+		return nil, "", fmt.Errorf("no OAuth state in authorize URL from server")
+	}
+
+	return authURL, state, nil
+}
+
+// finishLogin exchanges the code and state for tokens and
+// configures the client to use the tokens.
+func (svc *AuthorizationService) finishLogin(ctx context.Context, code, state string) (string, string, error) {
+	at, rt, _, err := svc.client.Authentication.ExchangeTokens(ctx, &OAuthExchangeOptions{Code: code, State: state})
+	if err != nil {
+		return "", "", fmt.Errorf("unable to exchange code for tokens: %w", err)
+	}
+
+	svc.client.Authentication.SetAccessAndRefreshAuth(at, rt)
+
+	return at, rt, nil
+}
+
+// authorizeURL follows the redirects for the login URL within
+// the server and returns the URL for authorizing with the SCM.
+func (svc *AuthorizationService) authorizeURL(ctx context.Context, loginURL string) (*url.URL, error) {
+	host := svc.client.baseURL.Host
+
+	// copy the HTTP client to stop at the first redirect leaving the server
+	hc := *svc.client.client
+	hc.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if req.URL.Host != host {
+			return http.ErrUseLastResponse
+		}
+
+		if len(via) >= 10 {
+			return fmt.Errorf("stopped after 10 redirects")
+		}
+
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, loginURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("User-Agent", svc.client.UserAgent)
+
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to start login: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	u, err := resp.Location()
+	if err != nil || u.Host == host {
+		return nil, fmt.Errorf("server did not redirect login to the SCM: %s", resp.Status)
+	}
+
+	return u, nil
+}
+
+// loginCallbackHandler returns a handler capturing the code from the first
+// login callback, rejecting the callback when the state does not match.
+// Requests for other paths, or without a code or error, are ignored.
+func loginCallbackHandler(state string, callbacks chan<- loginCallback) http.Handler {
+	mux := http.NewServeMux()
+
+	// the server redirects the browser to the root path
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		// ignore requests that are not a login callback
+		if !q.Has("code") && !q.Has("error") {
+			http.Error(w, "not a login callback", http.StatusBadRequest)
+
+			return
+		}
+
+		var cb loginCallback
+
+		switch {
+		case len(q.Get("error")) > 0:
+			cb.err = fmt.Errorf("login failed: %s %s", q.Get("error"), q.Get("error_description"))
+		case q.Get("state") != state:
+			cb.err = fmt.Errorf("login callback has unexpected OAuth state")
+		case len(q.Get("code")) == 0:
+			cb.err = fmt.Errorf("login callback is missing the code")
+		default:
+			cb.code = q.Get("code")
+		}
+
+		if cb.err != nil {
+			http.Error(w, cb.err.Error(), http.StatusBadRequest)
+		} else {
+			_, _ = w.Write([]byte("Authentication complete, you may close this window and return to the terminal."))
+		}
+
+		// only the first callback is used for the login
+		select {
+		case callbacks <- cb:
+		default:
+		}
+	})
+
+	return mux
+}
+
+// parseLoginCallback parses the code and state from the URL the browser
+// was sent to for the login on the port, its query string, or the code
+// and state separated by a space.
+func parseLoginCallback(input string, port int) (*OAuthExchangeOptions, error) {
+	var q url.Values
+
+	switch {
+	case strings.Contains(input, "://"):
+		u, err := url.Parse(input)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse URL: %w", err)
+		}
+
+		if (u.Hostname() != "127.0.0.1" && u.Hostname() != "localhost") || u.Port() != strconv.Itoa(port) {
+			return nil, fmt.Errorf("URL is not for 127.0.0.1:%d", port)
+		}
+
+		q = u.Query()
+	case strings.Contains(input, "="):
+		v, err := url.ParseQuery(strings.TrimPrefix(input, "?"))
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse query: %w", err)
+		}
+
+		q = v
+	default:
+		fields := strings.Fields(input)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("expected the code and state separated by a space")
+		}
+
+		q = url.Values{"code": {fields[0]}, "state": {fields[1]}}
+	}
+
+	if len(q.Get("error")) > 0 {
+		return nil, fmt.Errorf("login failed: %s %s", q.Get("error"), q.Get("error_description"))
+	}
+
+	if len(q.Get("code")) == 0 || len(q.Get("state")) == 0 {
+		return nil, fmt.Errorf("code and state must be provided")
+	}
+
+	return &OAuthExchangeOptions{Code: q.Get("code"), State: q.Get("state")}, nil
+}
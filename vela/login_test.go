@@ -3,8 +3,21 @@
 package vela
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	api "github.com/go-vela/server/api/types"
+	"github.com/go-vela/server/constants"
 )
 
 func TestAuthorizationService_GetLoginURL(t *testing.T) {
@@ -76,3 +89,358 @@ func TestAuthorizationService_GetLoginURL(t *testing.T) {
 		})
 	}
 }
+
+// newLoginServer returns a minimal stand-in for the Vela API
+// running the OAuth login flow with a fake SCM.
+func newLoginServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	return fakeServer(t, func(mux *http.ServeMux) {
+		mux.HandleFunc("GET /login", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("type") != "cli" {
+				t.Errorf("login type is %s, want cli", r.URL.Query().Get("type"))
+			}
+
+			redirect := fmt.Sprintf("http://%s/authenticate/cli/%s", r.Host, r.URL.Query().Get("port"))
+
+			http.Redirect(w, r, "/authenticate?redirect_uri="+url.QueryEscape(redirect), http.StatusTemporaryRedirect)
+		})
+
+		mux.HandleFunc("GET /authenticate", func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+
+			// start the flow with the SCM when there is no code
+			if len(q.Get("code")) == 0 {
+				v := url.Values{}
+				v.Add("redirect_uri", q.Get("redirect_uri"))
+				v.Add("state", "abc123")
+
+				http.Redirect(w, r, "https://scm.example.com/login/oauth/authorize?"+v.Encode(), http.StatusTemporaryRedirect)
+
+				return
+			}
+
+			if q.Get("code") != "42" || q.Get("state") != "abc123" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"error":"invalid code or state"}`))
+
+				return
+			}
+
+			http.SetCookie(w, &http.Cookie{Name: constants.RefreshTokenName, Value: "refresh"})
+
+			_ = json.NewEncoder(w).Encode(api.Token{Token: new("access")})
+		})
+
+		mux.HandleFunc("GET /authenticate/cli/{port}", func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, fmt.Sprintf("http://127.0.0.1:%s?%s", r.PathValue("port"), r.URL.RawQuery), http.StatusTemporaryRedirect)
+		})
+
+	})
+}
+
+// fakeBrowser returns a function standing in for the user authorizing
+// Vela with the SCM, calling back with the code and the state.
+func fakeBrowser(ctx context.Context, state string) func(string) error {
+	return func(authURL string) error {
+		u, err := url.Parse(authURL)
+		if err != nil {
+			return err
+		}
+
+		if len(state) == 0 {
+			state = u.Query().Get("state")
+		}
+
+		v := url.Values{}
+		v.Add("code", "42")
+		v.Add("state", state)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.Query().Get("redirect_uri")+"?"+v.Encode(), nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+
+		return resp.Body.Close()
+	}
+}
+
+func TestAuthorizationService_LoginInteractive(t *testing.T) {
+	s := newLoginServer(t)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+
+	// run test
+	at, rt, err := c.Authorization.LoginInteractive(ctx, &LoginInteractiveOptions{
+		Open:   fakeBrowser(ctx, ""),
+		Output: new(bytes.Buffer),
+	})
+	if err != nil {
+		t.Fatalf("LoginInteractive returned err: %v", err)
+	}
+
+	if at != "access" || rt != "refresh" {
+		t.Errorf("LoginInteractive returned tokens %s and %s", at, rt)
+	}
+
+	if !c.Authentication.HasAccessAndRefreshAuth() {
+		t.Errorf("LoginInteractive should have set access and refresh auth")
+	}
+}
+
+func TestAuthorizationService_LoginInteractive_State(t *testing.T) {
+	s := newLoginServer(t)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+
+	// run test
+	_, _, err := c.Authorization.LoginInteractive(ctx, &LoginInteractiveOptions{
+		Open:   fakeBrowser(ctx, "forged"),
+		Output: new(bytes.Buffer),
+	})
+	if err == nil || !strings.Contains(err.Error(), "state") {
+		t.Errorf("LoginInteractive should have returned err for forged state, got %v", err)
+	}
+
+	if c.Authentication.HasAuth() {
+		t.Errorf("LoginInteractive should not have set auth")
+	}
+}
+
+func TestAuthorizationService_LoginInteractive_OpenError(t *testing.T) {
+	s := newLoginServer(t)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+
+	out := new(bytes.Buffer)
+
+	// the browser fails to open and the user visits the printed URL
+	open := func(authURL string) error {
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+
+			_ = fakeBrowser(ctx, "")(authURL)
+		}()
+
+		return errors.New("no browser found")
+	}
+
+	// run test
+	at, _, err := c.Authorization.LoginInteractive(ctx, &LoginInteractiveOptions{Open: open, Output: out})
+	if err != nil {
+		t.Fatalf("LoginInteractive returned err: %v", err)
+	}
+
+	if at != "access" {
+		t.Errorf("LoginInteractive returned access token %s", at)
+	}
+
+	if !strings.Contains(out.String(), "https://scm.example.com/login/oauth/authorize") {
+		t.Errorf("LoginInteractive should have printed the authorize URL, got %s", out.String())
+	}
+}
+
+func TestAuthorizationService_LoginInteractive_Canceled(t *testing.T) {
+	s := newLoginServer(t)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+
+	out := new(bytes.Buffer)
+
+	// run test
+	_, _, err := c.Authorization.LoginInteractive(ctx, &LoginInteractiveOptions{Output: out})
+	if err == nil {
+		t.Errorf("LoginInteractive should have returned err")
+	}
+
+	if !strings.Contains(out.String(), "https://scm.example.com/login/oauth/authorize") {
+		t.Errorf("LoginInteractive should have printed the authorize URL, got %s", out.String())
+	}
+}
+
+func TestAuthorizationService_LoginHeadless(t *testing.T) {
+	s := newLoginServer(t)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	// the first line is not a valid callback and is asked for again
+	in := strings.NewReader("\nnot-a-code\nhttp://127.0.0.1:8400/?code=42&state=abc123\n")
+	out := new(bytes.Buffer)
+
+	// run test
+	at, rt, err := c.Authorization.LoginHeadless(t.Context(), &LoginHeadlessOptions{Input: in, Output: out})
+	if err != nil {
+		t.Fatalf("LoginHeadless returned err: %v", err)
+	}
+
+	if at != "access" || rt != "refresh" {
+		t.Errorf("LoginHeadless returned tokens %s and %s", at, rt)
+	}
+
+	if !strings.Contains(out.String(), "redirect_uri=") || !strings.Contains(out.String(), "expected the code and state") {
+		t.Errorf("LoginHeadless output is %s", out.String())
+	}
+}
+
+func TestAuthorizationService_LoginHeadless_State(t *testing.T) {
+	s := newLoginServer(t)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	// run test
+	_, _, err := c.Authorization.LoginHeadless(t.Context(), &LoginHeadlessOptions{
+		Input:  strings.NewReader("42 forged\n"),
+		Output: new(bytes.Buffer),
+	})
+	if err == nil || !strings.Contains(err.Error(), "state") {
+		t.Errorf("LoginHeadless should have returned err for forged state, got %v", err)
+	}
+
+	_, _, err = c.Authorization.LoginHeadless(t.Context(), &LoginHeadlessOptions{
+		Input:  strings.NewReader(""),
+		Output: new(bytes.Buffer),
+	})
+	if err == nil {
+		t.Errorf("LoginHeadless should have returned err without input")
+	}
+}
+
+func TestParseLoginCallback(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		name    string
+		input   string
+		want    *OAuthExchangeOptions
+		wantErr bool
+	}{
+		{
+			name:  "redirect URL",
+			input: "http://127.0.0.1:8400/?code=42&state=abc",
+			want:  &OAuthExchangeOptions{Code: "42", State: "abc"},
+		},
+		{
+			name:  "localhost URL",
+			input: "http://localhost:8400?state=abc&code=42",
+			want:  &OAuthExchangeOptions{Code: "42", State: "abc"},
+		},
+		{
+			name:  "query",
+			input: "?code=42&state=abc",
+			want:  &OAuthExchangeOptions{Code: "42", State: "abc"},
+		},
+		{
+			name:  "code and state",
+			input: "42 abc",
+			want:  &OAuthExchangeOptions{Code: "42", State: "abc"},
+		},
+		{
+			name:    "other host",
+			input:   "https://attacker.example.com:8400/?code=42&state=abc",
+			wantErr: true,
+		},
+		{
+			name:    "other port",
+			input:   "http://127.0.0.1:9000/?code=42&state=abc",
+			wantErr: true,
+		},
+		{
+			name:    "missing state",
+			input:   "code=42",
+			wantErr: true,
+		},
+		{
+			name:    "error",
+			input:   "http://127.0.0.1:8400/?error=access_denied&state=abc",
+			wantErr: true,
+		},
+		{
+			name:    "code only",
+			input:   "42",
+			wantErr: true,
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseLoginCallback(test.input, 8400)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("parseLoginCallback returned err: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("parseLoginCallback is %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLoginCallbackHandler(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		name   string
+		target string
+		status int
+		want   *loginCallback
+	}{
+		{
+			name:   "callback",
+			target: "/?code=42&state=abc123",
+			status: http.StatusOK,
+			want:   &loginCallback{code: "42"},
+		},
+		{
+			name:   "other path",
+			target: "/favicon.ico?code=42&state=abc123",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "no code or error",
+			target: "/?state=abc123",
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			callbacks := make(chan loginCallback, 1)
+
+			w := httptest.NewRecorder()
+
+			// run test
+			loginCallbackHandler("abc123", callbacks).ServeHTTP(w, httptest.NewRequestWithContext(t.Context(), http.MethodGet, test.target, nil))
+
+			if w.Code != test.status {
+				t.Errorf("loginCallbackHandler returned status %d, want %d", w.Code, test.status)
+			}
+
+			select {
+			case cb := <-callbacks:
+				if test.want == nil || cb != *test.want {
+					t.Errorf("loginCallbackHandler sent callback %v, want %v", cb, test.want)
+				}
+			default:
+				if test.want != nil {
+					t.Errorf("loginCallbackHandler should have sent the callback")
+				}
+			}
+		})
+	}
+}
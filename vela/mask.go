@@ -0,0 +1,208 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+
+	api "github.com/go-vela/server/api/types"
+	"github.com/go-vela/server/constants"
+)
+
+// minMaskLineLength is the minimum length for a single line of a
+// multi-line secret to be masked on its own, to avoid masking
+// short lines like braces that are common in any log.
+const minMaskLineLength = 4
+
+// SecretMasker redacts secret values from log content. Along with
+// the values themselves, the base64 and URL encoded variants are
+// masked, as well as each line of a multi-line value since commands
+// commonly print them one line at a time.
+type SecretMasker struct {
+	patterns [][]byte
+	longest  int
+}
+
+// NewSecretMasker returns a SecretMasker for the secret values.
+func NewSecretMasker(secrets ...string) *SecretMasker {
+	m := new(SecretMasker)
+
+	seen := make(map[string]bool)
+
+	add := func(p string) {
+		if len(p) == 0 || seen[p] {
+			return
+		}
+
+		seen[p] = true
+		m.patterns = append(m.patterns, []byte(p))
+		m.longest = max(m.longest, len(p))
+	}
+
+	for _, s := range secrets {
+		for _, v := range secretVariants(s) {
+			add(v)
+		}
+
+		if !strings.Contains(s, "\n") {
+			continue
+		}
+
+		for line := range strings.Lines(s) {
+			line = strings.TrimSpace(line)
+
+			if len(line) < minMaskLineLength {
+				continue
+			}
+
+			for _, v := range secretVariants(line) {
+				add(v)
+			}
+		}
+	}
+
+	// mask the longest values first so a value containing
+	// another value is not only partially masked
+	slices.SortStableFunc(m.patterns, func(a, b []byte) int {
+		return len(b) - len(a)
+	})
+
+	return m
+}
+
+// Mask returns a copy of the data with every secret value replaced.
+func (m *SecretMasker) Mask(data []byte) []byte {
+	out, _ := m.mask(nil, data, true)
+
+	return out
+}
+
+// MaskString returns the string with every secret value replaced.
+func (m *SecretMasker) MaskString(s string) string {
+	return string(m.Mask([]byte(s)))
+}
+
+// MaskLog replaces every secret value in the data for the log.
+func (m *SecretMasker) MaskLog(l *api.Log) {
+	if l == nil || l.Data == nil {
+		return
+	}
+
+	l.SetData(m.Mask(l.GetData()))
+}
+
+// Writer returns a MaskWriter writing masked data to w.
+func (m *SecretMasker) Writer(w io.Writer) *MaskWriter {
+	return &MaskWriter{masker: m, w: w}
+}
+
+// mask appends the masked data to dst. Unless final, the tail of
+// the data that could begin a secret value is not masked and is
+// returned so it can be prepended to the next chunk.
+func (m *SecretMasker) mask(dst, data []byte, final bool) ([]byte, []byte) {
+	if m == nil || len(m.patterns) == 0 {
+		return append(dst, data...), nil
+	}
+
+	// every position before the cut has room for the
+	// longest value, so no match can extend past the data
+	cut := len(data)
+	if !final {
+		cut = len(data) - (m.longest - 1)
+	}
+
+	i := 0
+
+scan:
+	for i < len(data) && i < cut {
+		for _, p := range m.patterns {
+			if bytes.HasPrefix(data[i:], p) {
+				dst = append(dst, constants.SecretLogMask...)
+				i += len(p)
+
+				continue scan
+			}
+		}
+
+		dst = append(dst, data[i])
+		i++
+	}
+
+	return dst, data[i:]
+}
+
+// secretVariants returns the value along with the
+// encodings of the value commonly found in logs.
+func secretVariants(s string) []string {
+	b := []byte(s)
+
+	return []string{
+		s,
+		strings.ReplaceAll(s, "\n", "\r\n"),
+		base64.StdEncoding.EncodeToString(b),
+		base64.RawStdEncoding.EncodeToString(b),
+		base64.URLEncoding.EncodeToString(b),
+		base64.RawURLEncoding.EncodeToString(b),
+		url.QueryEscape(s),
+		url.PathEscape(s),
+	}
+}
+
+// MaskWriter masks secret values in data streamed through it. The
+// end of each write that could be the start of a secret value is
+// held back until the next write, so values split across writes
+// are still masked. Flush or Close writes the held back data.
+type MaskWriter struct {
+	masker *SecretMasker
+	w      io.Writer
+	held   []byte
+}
+
+// Write masks the data and writes it to the underlying writer.
+func (mw *MaskWriter) Write(p []byte) (int, error) {
+	out, held := mw.masker.mask(nil, append(mw.held, p...), false)
+
+	mw.held = bytes.Clone(held)
+
+	_, err := mw.w.Write(out)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Flush masks and writes the data held back from previous writes.
+func (mw *MaskWriter) Flush() error {
+	if len(mw.held) == 0 {
+		return nil
+	}
+
+	out, _ := mw.masker.mask(nil, mw.held, true)
+
+	mw.held = nil
+
+	_, err := mw.w.Write(out)
+
+	return err
+}
+
+// Close flushes the held back data and closes
+// the underlying writer if it is an io.Closer.
+func (mw *MaskWriter) Close() error {
+	err := mw.Flush()
+	if err != nil {
+		return err
+	}
+
+	if c, ok := mw.w.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/url"
+	"testing"
+
+	api "github.com/go-vela/server/api/types"
+)
+
+func TestMask_SecretMasker_Mask(t *testing.T) {
+	// setup types
+	key := "-----BEGIN KEY-----\nabc123def456\n-----END KEY-----"
+
+	m := NewSecretMasker("p@ss/word", key, "")
+
+	// run tests
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			name: "raw",
+			data: "login with p@ss/word done",
+			want: "login with *** done",
+		},
+		{
+			name: "base64",
+			data: "auth " + base64.StdEncoding.EncodeToString([]byte("p@ss/word")),
+			want: "auth ***",
+		},
+		{
+			name: "url encoded",
+			data: "https://example.com?token=" + url.QueryEscape("p@ss/word"),
+			want: "https://example.com?token=***",
+		},
+		{
+			name: "multi-line",
+			data: "key:\n" + key + "\n",
+			want: "key:\n***\n",
+		},
+		{
+			name: "single line of multi-line",
+			data: "line: abc123def456\n",
+			want: "line: ***\n",
+		},
+		{
+			name: "no secrets",
+			data: "nothing to see",
+			want: "nothing to see",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := m.MaskString(tt.data)
+
+			if got != tt.want {
+				t.Errorf("MaskString is %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMask_SecretMasker_MaskLog(t *testing.T) {
+	// setup types
+	l := &api.Log{Data: new([]byte("token=hunter2"))}
+
+	// run test
+	NewSecretMasker("hunter2").MaskLog(l)
+
+	if got := string(l.GetData()); got != "token=***" {
+		t.Errorf("MaskLog is %q, want %q", got, "token=***")
+	}
+
+	// should not panic on a nil log
+	NewSecretMasker("hunter2").MaskLog(nil)
+}
+
+func TestMask_MaskWriter(t *testing.T) {
+	// setup types
+	data := "export TOKEN=hunter2\necho aHVudGVyMg== | base64 -d\nhunter hunter2"
+	want := "export TOKEN=***\necho *** | base64 -d\nhunter ***"
+
+	m := NewSecretMasker("hunter2")
+
+	// run test for every chunk size to split the
+	// secrets at every possible position
+	for size := 1; size <= len(data); size++ {
+		buf := new(bytes.Buffer)
+		w := m.Writer(buf)
+
+		for i := 0; i < len(data); i += size {
+			_, err := w.Write([]byte(data[i:min(i+size, len(data))]))
+			if err != nil {
+				t.Fatalf("Write returned err: %v", err)
+			}
+		}
+
+		err := w.Close()
+		if err != nil {
+			t.Errorf("Close returned err: %v", err)
+		}
+
+		if buf.String() != want {
+			t.Errorf("MaskWriter with chunk size %d is %q, want %q", size, buf.String(), want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"context"
+
+	api "github.com/go-vela/server/api/types"
+)
+
+// OIDCService handles retrieving the OpenID Connect
+// details from the server methods of the Vela API.
+type OIDCService service
+
+// GetOpenIDConfig returns the OpenID Connect configuration for the server.
+func (svc *OIDCService) GetOpenIDConfig(ctx context.Context) (*api.OpenIDConfig, *Response, error) {
+	// set the API endpoint path we send the request to
+	u := "/_services/token/.well-known/openid-configuration"
+
+	// API OpenIDConfig type we want to return
+	v := new(api.OpenIDConfig)
+
+	// send request using client
+	resp, err := svc.client.Call(ctx, "GET", u, nil, v)
+
+	return v, resp, err
+}
+
+// GetJWKS returns the public keys used to sign ID tokens for the server.
+func (svc *OIDCService) GetJWKS(ctx context.Context) (*api.JWKSet, *Response, error) {
+	// set the API endpoint path we send the request to
+	u := "/_services/token/.well-known/jwks"
+
+	// API JWKSet type we want to return
+	v := new(api.JWKSet)
+
+	// send request using client
+	resp, err := svc.client.Call(ctx, "GET", u, nil, v)
+
+	return v, resp, err
+}
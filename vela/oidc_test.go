@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/go-vela/server/mock/server"
+)
+
+func TestOIDC_GetOpenIDConfig_200(t *testing.T) {
+	// setup context
+	gin.SetMode(gin.TestMode)
+
+	s := httptest.NewServer(server.FakeHandler())
+	c, _ := NewClient(s.URL, "", nil)
+
+	// run test
+	got, resp, err := c.OIDC.GetOpenIDConfig(t.Context())
+	if err != nil {
+		t.Errorf("GetOpenIDConfig returned err: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GetOpenIDConfig returned %v, want %v", resp.StatusCode, http.StatusOK)
+	}
+
+	if got.Issuer != "https://vela.com/_services/token" {
+		t.Errorf("GetOpenIDConfig issuer is %v", got.Issuer)
+	}
+}
+
+func TestOIDC_GetJWKS_200(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_services/token/.well-known/jwks" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"not found"}`))
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"keys":[{"kty":"RSA","kid":"foo","e":"AQAB","n":"bar"}]}`))
+	}))
+	defer s.Close()
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	// run test
+	got, resp, err := c.OIDC.GetJWKS(t.Context())
+	if err != nil {
+		t.Errorf("GetJWKS returned err: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GetJWKS returned %v, want %v", resp.StatusCode, http.StatusOK)
+	}
+
+	if len(got.Keys) != 1 || got.Keys[0].Kid != "foo" {
+		t.Errorf("GetJWKS is %v", got)
+	}
+}
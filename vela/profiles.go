@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+)
+
+const (
+	// ProfileEnv defines the environment variable
+	// used to select the current profile.
+	ProfileEnv = "VELA_PROFILE"
+
+	// DefaultProfile defines the name of the profile kept
+	// in the api section of the config for the CLI.
+	DefaultProfile = "default"
+)
+
+// Profiles manages named profiles for Vela servers kept in the config
+// file for the CLI, similar to contexts in a kubeconfig. Methods taking
+// a profile name use the current profile when the name is empty.
+type Profiles struct {
+	store *FileCredentialStore
+}
+
+// LoadProfiles returns the profiles kept in the config file at the path.
+// When the path is empty, the default path for the CLI config is used.
+func LoadProfiles(path string) (*Profiles, error) {
+	store, err := NewFileCredentialStore(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Profiles{store: store}, nil
+}
+
+// Store returns the credential store the profiles are kept in.
+func (p *Profiles) Store() *FileCredentialStore {
+	return p.store
+}
+
+// Names returns the names of the profiles, starting
+// with the default profile when it is configured.
+func (p *Profiles) Names() ([]string, error) {
+	names, err := p.store.Profiles()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.store.Load("")
+	if err == nil {
+		names = append([]string{DefaultProfile}, names...)
+	}
+
+	return names, nil
+}
+
+// Current returns the name of the current profile, which is the profile
+// in VELA_PROFILE when set, otherwise the profile selected in the config.
+func (p *Profiles) Current() (string, error) {
+	if name := os.Getenv(ProfileEnv); len(name) > 0 {
+		return name, nil
+	}
+
+	name, err := p.store.CurrentProfile()
+	if err != nil {
+		return "", err
+	}
+
+	if len(name) == 0 {
+		return DefaultProfile, nil
+	}
+
+	return name, nil
+}
+
+// Use selects the profile as the current profile in the config.
+func (p *Profiles) Use(name string) error {
+	if len(name) == 0 {
+		return fmt.Errorf("no profile provided")
+	}
+
+	_, err := p.store.Load(storeProfile(name))
+	if err != nil {
+		return err
+	}
+
+	return p.store.SetCurrentProfile(storeProfile(name))
+}
+
+// Get returns the credentials for the profile.
+func (p *Profiles) Get(name string) (*Credentials, error) {
+	name, err := p.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.store.Load(storeProfile(name))
+}
+
+// Set replaces the credentials for the profile.
+func (p *Profiles) Set(name string, creds *Credentials) error {
+	name, err := p.resolve(name)
+	if err != nil {
+		return err
+	}
+
+	return p.store.Save(storeProfile(name), creds)
+}
+
+// Client returns a new Vela API client for the profile, configured with the
+// authentication for the profile. Access and refresh tokens refreshed by the
+// client are saved back to the profile. The id and httpClient are passed to
+// NewClient.
+func (p *Profiles) Client(name, id string, httpClient *http.Client) (*Client, error) {
+	name, err := p.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+
+	creds, err := p.store.Load(storeProfile(name))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(creds.Address) == 0 {
+		return nil, fmt.Errorf("no address configured for profile %s", name)
+	}
+
+	c, err := NewClient(creds.Address, id, httpClient)
+	if err != nil {
+		return nil, err
+	}
+
+	switch creds.GetAuthType() {
+	case CredentialAuthOAuth:
+		c.Authentication.SetAccessAndRefreshAuth(creds.AccessToken, creds.RefreshToken)
+		c.Authentication.SetCredentialStore(p.store, storeProfile(name))
+	case CredentialAuthPersonalAccessToken:
+		c.Authentication.SetPersonalAccessTokenAuth(creds.PersonalAccessToken)
+	case CredentialAuthToken:
+		c.Authentication.SetTokenAuth(creds.Token)
+	case "":
+		// no authentication configured for the profile
+	default:
+		return nil, fmt.Errorf("unsupported auth type %s for profile %s", creds.AuthType, name)
+	}
+
+	return c, nil
+}
+
+// resolve returns the name of the profile, or the
+// name of the current profile when it is empty.
+func (p *Profiles) resolve(name string) (string, error) {
+	if len(name) > 0 {
+		return name, nil
+	}
+
+	return p.Current()
+}
+
+// storeProfile returns the name of the profile in the credential store,
+// where the default profile is kept under the empty name.
+func storeProfile(name string) string {
+	if name == DefaultProfile {
+		return ""
+	}
+
+	return name
+}
@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func testProfiles(t *testing.T) *Profiles {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+
+	cfg := `api:
+  addr: https://vela.example.com
+  token:
+    access: access
+    refresh: refresh
+profiles:
+  staging:
+    addr: https://vela-staging.example.com
+    auth: token
+    token:
+      value: worker
+  github:
+    addr: https://vela-github.example.com
+    token:
+      personal_access: pat
+log:
+  level: info
+`
+
+	err := os.WriteFile(path, []byte(cfg), 0o600)
+	if err != nil {
+		t.Fatalf("unable to write config: %v", err)
+	}
+
+	p, err := LoadProfiles(path)
+	if err != nil {
+		t.Fatalf("LoadProfiles returned err: %v", err)
+	}
+
+	return p
+}
+
+func TestProfiles_Names(t *testing.T) {
+	p := testProfiles(t)
+
+	// run test
+	got, err := p.Names()
+	if err != nil {
+		t.Errorf("Names returned err: %v", err)
+	}
+
+	want := []string{DefaultProfile, "github", "staging"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Names is %v, want %v", got, want)
+	}
+}
+
+func TestProfiles_Current(t *testing.T) {
+	p := testProfiles(t)
+
+	// run test
+	got, _ := p.Current()
+	if got != DefaultProfile {
+		t.Errorf("Current is %s, want %s", got, DefaultProfile)
+	}
+
+	err := p.Use("staging")
+	if err != nil {
+		t.Errorf("Use returned err: %v", err)
+	}
+
+	got, _ = p.Current()
+	if got != "staging" {
+		t.Errorf("Current is %s, want staging", got)
+	}
+
+	t.Setenv(ProfileEnv, "github")
+
+	got, _ = p.Current()
+	if got != "github" {
+		t.Errorf("Current is %s, want github", got)
+	}
+
+	err = p.Use("dev")
+	if err == nil {
+		t.Errorf("Use should have returned err for unknown profile")
+	}
+}
+
+func TestProfiles_Client(t *testing.T) {
+	p := testProfiles(t)
+
+	// run test
+	c, err := p.Client("", "", nil)
+	if err != nil {
+		t.Fatalf("Client returned err: %v", err)
+	}
+
+	if c.baseURL.String() != "https://vela.example.com" || !c.Authentication.HasAccessAndRefreshAuth() {
+		t.Errorf("Client should use the default profile with access and refresh auth")
+	}
+
+	if c.Authentication.store != p.Store() {
+		t.Errorf("Client should save refreshed tokens to the profile")
+	}
+
+	c, err = p.Client("staging", "", nil)
+	if err != nil {
+		t.Fatalf("Client returned err: %v", err)
+	}
+
+	if c.baseURL.String() != "https://vela-staging.example.com" || !c.Authentication.HasTokenAuth() {
+		t.Errorf("Client should use the staging profile with token auth")
+	}
+
+	t.Setenv(ProfileEnv, "github")
+
+	c, err = p.Client("", "", nil)
+	if err != nil {
+		t.Fatalf("Client returned err: %v", err)
+	}
+
+	if !c.Authentication.HasPersonalAccessTokenAuth() {
+		t.Errorf("Client should use the github profile with personal access token auth")
+	}
+
+	_, err = p.Client("dev", "", nil)
+	if err == nil {
+		t.Errorf("Client should have returned err for unknown profile")
+	}
+}
+
+func TestProfiles_Set(t *testing.T) {
+	p := testProfiles(t)
+
+	creds := &Credentials{Address: "https://vela-dev.example.com", AuthType: CredentialAuthToken, Token: "dev"}
+
+	// run test
+	err := p.Set("dev", creds)
+	if err != nil {
+		t.Fatalf("Set returned err: %v", err)
+	}
+
+	got, err := p.Get("dev")
+	if err != nil || *got != *creds {
+		t.Errorf("Get is %v (err %v), want %v", got, err, creds)
+	}
+}
@@ -0,0 +1,437 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	api "github.com/go-vela/server/api/types"
+	"github.com/go-vela/server/constants"
+)
+
+const (
+	// unknownRoute is the route reported for builds
+	// the route could not be looked up for.
+	unknownRoute = "unknown"
+
+	// queueRouteLookups is the maximum number of builds
+	// looked up at once to find the route they were queued on.
+	queueRouteLookups = 8
+)
+
+// QueueStatsOptions specifies the optional parameters
+// to the Queue.Stats and Queue.Watch methods.
+type QueueStatsOptions struct {
+	// Only include builds created after the time.
+	//
+	// Default: 24 hours ago, set by the server
+	After time.Time
+
+	// Percentiles of the age of pending builds to report.
+	//
+	// Default: 50, 90 and 99
+	Percentiles []float64
+
+	// Time between snapshots of the queue when watching.
+	//
+	// Default: 30s
+	Interval time.Duration
+
+	// Age of the oldest pending build after which OnAlert is
+	// called when watching. When zero, no alerts are sent.
+	AlertAge time.Duration
+
+	// Function called with the snapshot of the queue when the
+	// oldest pending build first waits longer than AlertAge.
+	// It is not called again until the queue has recovered.
+	OnAlert func(*QueueStats)
+
+	// Function called with the snapshot of the queue when the
+	// oldest pending build no longer waits longer than AlertAge
+	// after an alert was sent.
+	OnRecover func(*QueueStats)
+
+	// Function called when a snapshot of the queue fails while
+	// watching. When not provided, Watch returns the error.
+	OnError func(error)
+}
+
+// QueueCount represents the number of builds in the queue by status.
+type QueueCount struct {
+	Pending int `json:"pending"`
+	Running int `json:"running"`
+}
+
+// add counts the build with the status.
+func (c QueueCount) add(status string) QueueCount {
+	switch status {
+	case constants.StatusPending:
+		c.Pending++
+	case constants.StatusRunning:
+		c.Running++
+	}
+
+	return c
+}
+
+// QueuePercentile represents the age of pending builds at a percentile.
+type QueuePercentile struct {
+	Percentile float64       `json:"percentile"`
+	Age        time.Duration `json:"age"`
+}
+
+// QueueStats represents a snapshot of the builds in the queue.
+type QueueStats struct {
+	At time.Time `json:"at"`
+
+	Total QueueCount `json:"total"`
+
+	// Builds by the route they were queued on, the flavor from
+	// the route and the full name of the repo for the build.
+	ByRoute  map[string]QueueCount `json:"by_route"`
+	ByFlavor map[string]QueueCount `json:"by_flavor"`
+	ByRepo   map[string]QueueCount `json:"by_repo"`
+
+	// Age of the pending builds.
+	Oldest      time.Duration     `json:"oldest"`
+	Percentiles []QueuePercentile `json:"percentiles"`
+
+	// Builds in the queue as <org>/<repo>#<number>.
+	Builds []string `json:"builds"`
+}
+
+// QueueDelta represents the changes between two snapshots of the queue.
+type QueueDelta struct {
+	Total QueueCount `json:"total"`
+
+	// Change in builds by route, flavor and repo. Keys
+	// without any change are omitted.
+	ByRoute  map[string]QueueCount `json:"by_route"`
+	ByFlavor map[string]QueueCount `json:"by_flavor"`
+	ByRepo   map[string]QueueCount `json:"by_repo"`
+
+	// Builds added to and removed from the queue.
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// Stats returns a snapshot of the builds in the queue. The queue
+// does not include the route for builds, so each build is looked
+// up to find the route it was queued on. The routes are cached by
+// the client for as long as the builds are queued.
+func (qvc *QueueService) Stats(ctx context.Context, opt *QueueStatsOptions) (*QueueStats, error) {
+	if opt == nil {
+		opt = new(QueueStatsOptions)
+	}
+
+	return qvc.stats(ctx, opt)
+}
+
+// Watch takes a snapshot of the queue on every interval until the context
+// is canceled, calling fn with the snapshot and the changes since the last
+// snapshot. The first snapshot is compared against an empty queue.
+func (qvc *QueueService) Watch(ctx context.Context, opt *QueueStatsOptions, fn func(*QueueStats, *QueueDelta)) error {
+	if opt == nil {
+		opt = new(QueueStatsOptions)
+	}
+
+	interval := opt.Interval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	prev := new(QueueStats)
+
+	// whether an alert was sent for the queue
+	alerting := false
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s, err := qvc.stats(ctx, opt)
+
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case err != nil && opt.OnError == nil:
+			return err
+		case err != nil:
+			opt.OnError(err)
+		default:
+			fn(s, prev.Delta(s))
+
+			// only alert when the queue passes the threshold and
+			// once it recovers instead of on every snapshot
+			if opt.AlertAge > 0 && (s.Oldest >= opt.AlertAge) != alerting {
+				alerting = !alerting
+
+				switch {
+				case alerting && opt.OnAlert != nil:
+					opt.OnAlert(s)
+				case !alerting && opt.OnRecover != nil:
+					opt.OnRecover(s)
+				}
+			}
+
+			prev = s
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Delta returns the changes from the snapshot to the next snapshot.
+func (s *QueueStats) Delta(next *QueueStats) *QueueDelta {
+	d := &QueueDelta{
+		Total: QueueCount{
+			Pending: next.Total.Pending - s.Total.Pending,
+			Running: next.Total.Running - s.Total.Running,
+		},
+		ByRoute:  queueCountDelta(s.ByRoute, next.ByRoute),
+		ByFlavor: queueCountDelta(s.ByFlavor, next.ByFlavor),
+		ByRepo:   queueCountDelta(s.ByRepo, next.ByRepo),
+	}
+
+	for _, b := range next.Builds {
+		if !slices.Contains(s.Builds, b) {
+			d.Added = append(d.Added, b)
+		}
+	}
+
+	for _, b := range s.Builds {
+		if !slices.Contains(next.Builds, b) {
+			d.Removed = append(d.Removed, b)
+		}
+	}
+
+	return d
+}
+
+// stats returns a snapshot of the queue.
+func (qvc *QueueService) stats(ctx context.Context, opt *QueueStatsOptions) (*QueueStats, error) {
+	qo := new(GetQueueOptions)
+
+	if !opt.After.IsZero() {
+		qo.After = strconv.FormatInt(opt.After.Unix(), 10)
+	}
+
+	// the queue is not paginated by the server,
+	// so every build is returned in one request
+	queue, _, err := qvc.client.Admin.Build.GetQueue(ctx, qo)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list queue: %w", err)
+	}
+
+	now := time.Now().UTC()
+
+	s := &QueueStats{
+		At:       now,
+		ByRoute:  make(map[string]QueueCount),
+		ByFlavor: make(map[string]QueueCount),
+		ByRepo:   make(map[string]QueueCount),
+		Builds:   []string{},
+	}
+
+	var ages []time.Duration
+
+	routes := qvc.routes(ctx, *queue, nil)
+
+	for _, b := range *queue {
+		name := fmt.Sprintf("%s#%d", b.GetFullName(), b.GetNumber())
+		route := routes[name]
+		status := b.GetStatus()
+
+		s.Total = s.Total.add(status)
+		s.ByRoute[route] = s.ByRoute[route].add(status)
+		s.ByFlavor[routeFlavor(route)] = s.ByFlavor[routeFlavor(route)].add(status)
+		s.ByRepo[b.GetFullName()] = s.ByRepo[b.GetFullName()].add(status)
+		s.Builds = append(s.Builds, name)
+
+		if status == constants.StatusPending {
+			ages = append(ages, now.Sub(time.Unix(b.GetCreated(), 0)))
+		}
+	}
+
+	slices.Sort(ages)
+
+	if len(ages) > 0 {
+		s.Oldest = ages[len(ages)-1]
+	}
+
+	percentiles := opt.Percentiles
+	if len(percentiles) == 0 {
+		percentiles = []float64{50, 90, 99}
+	}
+
+	for _, p := range percentiles {
+		s.Percentiles = append(s.Percentiles, QueuePercentile{Percentile: p, Age: percentile(ages, p)})
+	}
+
+	return s, nil
+}
+
+// queueRoutes caches the routes builds in the queue were queued on.
+// The route for a build never changes, so it is kept for as long as
+// the build is queued.
+type queueRoutes struct {
+	mu     sync.Mutex
+	routes map[string]string
+}
+
+// routes returns the route each build in the queue matching the filter,
+// or every build without one, was queued on by <org>/<repo>#<number>.
+// Builds without a cached route are looked up a few at a time and the
+// builds a lookup fails for are reported on the unknown route, to be
+// looked up again for the next snapshot.
+func (qvc *QueueService) routes(ctx context.Context, queue []api.QueueBuild, filter func(*api.QueueBuild) bool) map[string]string {
+	cache := &qvc.client.queueRoutes
+
+	routes := make(map[string]string)
+	queued := make(map[string]bool)
+	missing := make(map[string]*api.QueueBuild)
+
+	cache.mu.Lock()
+
+	if cache.routes == nil {
+		cache.routes = make(map[string]string)
+	}
+
+	for i := range queue {
+		b := &queue[i]
+
+		name := fmt.Sprintf("%s#%d", b.GetFullName(), b.GetNumber())
+		queued[name] = true
+
+		if filter != nil && !filter(b) {
+			continue
+		}
+
+		route, ok := cache.routes[name]
+		if !ok {
+			missing[name] = b
+
+			continue
+		}
+
+		routes[name] = route
+	}
+
+	// forget the routes for builds that left the queue
+	for name := range cache.routes {
+		if !queued[name] {
+			delete(cache.routes, name)
+		}
+	}
+
+	cache.mu.Unlock()
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	// limit the builds looked up at once
+	limit := make(chan struct{}, queueRouteLookups)
+
+	for name, b := range missing {
+		wg.Go(func() {
+			limit <- struct{}{}
+			defer func() { <-limit }()
+
+			route, err := qvc.route(ctx, b)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				logrus.Debugf("unable to find route for build %s: %v", name, err)
+
+				routes[name] = unknownRoute
+
+				return
+			}
+
+			routes[name] = route
+
+			cache.mu.Lock()
+			cache.routes[name] = route
+			cache.mu.Unlock()
+		})
+	}
+
+	wg.Wait()
+
+	return routes
+}
+
+// route returns the route the build was queued on.
+func (qvc *QueueService) route(ctx context.Context, b *api.QueueBuild) (string, error) {
+	org, repo, _ := strings.Cut(b.GetFullName(), "/")
+
+	build, _, err := qvc.client.Build.Get(ctx, org, repo, int64(b.GetNumber()))
+	if err != nil {
+		return "", fmt.Errorf("unable to get build %s/%s/%d: %w", org, repo, b.GetNumber(), err)
+	}
+
+	if len(build.GetRoute()) == 0 {
+		return constants.DefaultRoute, nil
+	}
+
+	return build.GetRoute(), nil
+}
+
+// routeFlavor returns the flavor from the route. Routes are composed
+// of the flavor and platform requested by the pipeline, so a route
+// with a single part may name either and is returned as is.
+func routeFlavor(route string) string {
+	flavor, _, _ := strings.Cut(route, ":")
+
+	return flavor
+}
+
+// percentile returns the value at the percentile of
+// the sorted values using the nearest rank method.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
+
+// queueCountDelta returns the change in counts for every key.
+func queueCountDelta(prev, next map[string]QueueCount) map[string]QueueCount {
+	d := make(map[string]QueueCount)
+
+	for k, n := range next {
+		p := prev[k]
+
+		if n != p {
+			d[k] = QueueCount{Pending: n.Pending - p.Pending, Running: n.Running - p.Running}
+		}
+	}
+
+	for k, p := range prev {
+		if _, ok := next[k]; !ok {
+			d[k] = QueueCount{Pending: -p.Pending, Running: -p.Running}
+		}
+	}
+
+	return d
+}
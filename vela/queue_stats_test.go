@@ -0,0 +1,262 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	api "github.com/go-vela/server/api/types"
+	"github.com/go-vela/server/constants"
+)
+
+// queueServer is a minimal stand-in for the Vela
+// API reporting the builds in the queue.
+type queueServer struct {
+	sync.Mutex
+
+	queues [][]api.QueueBuild
+	gets   int
+	lists  int
+}
+
+func (qs *queueServer) routes(mux *http.ServeMux) {
+	routes := map[string]string{
+		"github/octocat/1":     "",
+		"github/octocat/2":     "large:linux",
+		"github/hello-world/3": "large:linux",
+		"github/hello-world/4": "gpu",
+	}
+
+	mux.HandleFunc("GET /api/v1/admin/builds/queue", func(w http.ResponseWriter, _ *http.Request) {
+		qs.Lock()
+		defer qs.Unlock()
+
+		// the server ignores the paging parameters for the queue
+		qs.lists++
+
+		q := qs.queues[0]
+		if len(qs.queues) > 1 {
+			qs.queues = qs.queues[1:]
+		}
+
+		_ = json.NewEncoder(w).Encode(q)
+	})
+
+	mux.HandleFunc("GET /api/v1/repos/{org}/{repo}/builds/{build}", func(w http.ResponseWriter, r *http.Request) {
+		qs.Lock()
+		defer qs.Unlock()
+
+		qs.gets++
+
+		route, ok := routes[r.PathValue("org")+"/"+r.PathValue("repo")+"/"+r.PathValue("build")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"build not found"}`))
+
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(api.Build{Route: new(route)})
+	})
+}
+
+func testQueueBuild(repo string, number int32, status string, age time.Duration) api.QueueBuild {
+	return api.QueueBuild{
+		FullName: new(repo),
+		Number:   new(number),
+		Status:   new(status),
+		Created:  new(time.Now().Add(-age).Unix()),
+	}
+}
+
+func TestQueue_Stats(t *testing.T) {
+	qs := &queueServer{queues: [][]api.QueueBuild{{
+		testQueueBuild("github/octocat", 1, constants.StatusRunning, time.Hour),
+		testQueueBuild("github/octocat", 2, constants.StatusPending, 10*time.Minute),
+		testQueueBuild("github/hello-world", 3, constants.StatusPending, 20*time.Minute),
+		testQueueBuild("github/hello-world", 4, constants.StatusPending, 30*time.Minute),
+		testQueueBuild("github/hello-world", 5, constants.StatusRunning, time.Minute),
+	}}}
+
+	s := fakeServer(t, qs.routes)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	// run test
+	got, err := c.Queue.Stats(t.Context(), &QueueStatsOptions{Percentiles: []float64{50, 100}})
+	if err != nil {
+		t.Fatalf("Stats returned err: %v", err)
+	}
+
+	if got.Total != (QueueCount{Pending: 3, Running: 2}) {
+		t.Errorf("Stats total is %v", got.Total)
+	}
+
+	// the build that fails to be looked up is counted on the unknown route
+	wantRoutes := map[string]QueueCount{
+		constants.DefaultRoute: {Running: 1},
+		"large:linux":          {Pending: 2},
+		"gpu":                  {Pending: 1},
+		"unknown":              {Running: 1},
+	}
+
+	if !reflect.DeepEqual(got.ByRoute, wantRoutes) {
+		t.Errorf("Stats routes are %v, want %v", got.ByRoute, wantRoutes)
+	}
+
+	if got.ByFlavor["large"] != (QueueCount{Pending: 2}) {
+		t.Errorf("Stats flavors are %v", got.ByFlavor)
+	}
+
+	if got.ByRepo["github/hello-world"] != (QueueCount{Pending: 2, Running: 1}) {
+		t.Errorf("Stats repos are %v", got.ByRepo)
+	}
+
+	if got.Percentiles[0].Age.Round(time.Minute) != 20*time.Minute || got.Percentiles[1].Age != got.Oldest {
+		t.Errorf("Stats percentiles are %v", got.Percentiles)
+	}
+
+	if got.Oldest.Round(time.Minute) != 30*time.Minute {
+		t.Errorf("Stats oldest is %v", got.Oldest)
+	}
+}
+
+func TestQueue_Stats_Unpaginated(t *testing.T) {
+	// setup types
+	queue := []api.QueueBuild{}
+
+	for i := range int32(150) {
+		queue = append(queue, testQueueBuild("github/octocat", 1000+i, constants.StatusPending, time.Minute))
+	}
+
+	qs := &queueServer{queues: [][]api.QueueBuild{queue}}
+
+	s := fakeServer(t, qs.routes)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	// run test
+	got, err := c.Queue.Stats(t.Context(), nil)
+	if err != nil {
+		t.Fatalf("Stats returned err: %v", err)
+	}
+
+	if got.Total != (QueueCount{Pending: 150}) {
+		t.Errorf("Stats total is %v", got.Total)
+	}
+
+	if qs.lists != 1 {
+		t.Errorf("Stats listed the queue %d times, want 1", qs.lists)
+	}
+}
+
+func TestQueue_Watch(t *testing.T) {
+	qs := &queueServer{queues: [][]api.QueueBuild{
+		{
+			testQueueBuild("github/octocat", 1, constants.StatusPending, time.Minute),
+			testQueueBuild("github/octocat", 2, constants.StatusPending, time.Minute),
+		},
+		{
+			testQueueBuild("github/octocat", 2, constants.StatusRunning, time.Minute),
+			testQueueBuild("github/hello-world", 4, constants.StatusPending, time.Hour),
+		},
+		{
+			testQueueBuild("github/octocat", 2, constants.StatusRunning, time.Minute),
+			testQueueBuild("github/hello-world", 4, constants.StatusPending, time.Hour),
+		},
+		{
+			testQueueBuild("github/octocat", 2, constants.StatusRunning, time.Minute),
+		},
+	}}
+
+	s := fakeServer(t, qs.routes)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	var (
+		deltas     []*QueueDelta
+		alerts     int
+		recoveries int
+	)
+
+	// run test
+	err := c.Queue.Watch(ctx, &QueueStatsOptions{
+		Interval:  time.Millisecond,
+		AlertAge:  30 * time.Minute,
+		OnAlert:   func(*QueueStats) { alerts++ },
+		OnRecover: func(*QueueStats) { recoveries++ },
+	}, func(_ *QueueStats, d *QueueDelta) {
+		deltas = append(deltas, d)
+
+		if len(deltas) == 4 {
+			cancel()
+		}
+	})
+	if err != nil {
+		t.Errorf("Watch returned err: %v", err)
+	}
+
+	if len(deltas) != 4 {
+		t.Fatalf("Watch emitted %d deltas, want 4", len(deltas))
+	}
+
+	if !reflect.DeepEqual(deltas[0].Added, []string{"github/octocat#1", "github/octocat#2"}) {
+		t.Errorf("Watch first delta added %v", deltas[0].Added)
+	}
+
+	d := deltas[1]
+
+	if d.Total != (QueueCount{Pending: -1, Running: 1}) {
+		t.Errorf("Watch delta total is %v", d.Total)
+	}
+
+	if !reflect.DeepEqual(d.Added, []string{"github/hello-world#4"}) || !reflect.DeepEqual(d.Removed, []string{"github/octocat#1"}) {
+		t.Errorf("Watch delta added %v and removed %v", d.Added, d.Removed)
+	}
+
+	wantRepos := map[string]QueueCount{
+		"github/octocat":     {Pending: -2, Running: 1},
+		"github/hello-world": {Pending: 1},
+	}
+
+	if !reflect.DeepEqual(d.ByRepo, wantRepos) {
+		t.Errorf("Watch delta repos are %v, want %v", d.ByRepo, wantRepos)
+	}
+
+	// the alert is only sent when the queue passes the
+	// threshold and again once the queue recovers
+	if alerts != 1 || recoveries != 1 {
+		t.Errorf("Watch sent %d alerts and %d recoveries, want 1 and 1", alerts, recoveries)
+	}
+
+	// routes are only looked up once per build
+	if qs.gets != 3 {
+		t.Errorf("Watch looked up %d builds, want 3", qs.gets)
+	}
+}
+
+func TestQueue_Watch_Error(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"error":"boom"}`))
+	}))
+	defer s.Close()
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	// run test
+	err := c.Queue.Watch(t.Context(), nil, func(*QueueStats, *QueueDelta) {})
+	if err == nil {
+		t.Errorf("Watch should have returned err")
+	}
+}
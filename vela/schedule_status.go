@@ -0,0 +1,213 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	api "github.com/go-vela/server/api/types"
+	"github.com/go-vela/server/constants"
+)
+
+// ScheduleStatusOptions specifies the optional parameters
+// to the Schedule.Status method.
+type ScheduleStatusOptions struct {
+	// Start of the window to report runs for.
+	//
+	// Default: 24 hours before Until
+	Since time.Time
+
+	// End of the window to report runs for.
+	//
+	// Default: now
+	Until time.Time
+
+	// Time allowed between a schedule being due and the
+	// build being created before the run is considered
+	// missed, accounting for the interval and jitter of
+	// the server processing schedules.
+	//
+	// Default: 15m
+	Grace time.Duration
+
+	// Number of results to request per page when listing resources.
+	//
+	// Default: 100
+	PerPage int
+}
+
+// ScheduleRun represents a single expected run of a schedule.
+type ScheduleRun struct {
+	// Time the schedule was due to run.
+	Due time.Time
+
+	// Build triggered for the run, nil when the run was missed.
+	Build *api.Build
+}
+
+// ScheduleStatus represents the recent runs for a schedule.
+type ScheduleStatus struct {
+	Schedule *api.Schedule
+
+	// Time the schedule runs next.
+	NextRun time.Time
+
+	// Time the server last triggered the schedule.
+	LastRun time.Time
+
+	// Runs due within the window, excluding runs still
+	// within the grace period.
+	Runs []ScheduleRun
+
+	// Times within the window without a build.
+	Missed []time.Time
+
+	// Builds within the window that did not succeed.
+	Failed []*api.Build
+
+	// Error parsing the entry for the schedule.
+	Err error
+}
+
+// Status returns the next run and the missed or failed runs within
+// the window for every schedule of the repo, by correlating each
+// schedule with the builds created for the schedule event.
+func (svc *ScheduleService) Status(ctx context.Context, org, repo string, opt *ScheduleStatusOptions) ([]*ScheduleStatus, error) {
+	if opt == nil {
+		opt = new(ScheduleStatusOptions)
+	}
+
+	until := opt.Until
+	if until.IsZero() {
+		until = time.Now()
+	}
+
+	since := opt.Since
+	if since.IsZero() {
+		since = until.Add(-24 * time.Hour)
+	}
+
+	grace := opt.Grace
+	if grace <= 0 {
+		grace = 15 * time.Minute
+	}
+
+	schedules, err := listAll(opt.PerPage, func(lo ListOptions) (*[]api.Schedule, *Response, error) {
+		return svc.GetAll(ctx, org, repo, &lo)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list schedules for %s/%s: %w", org, repo, err)
+	}
+
+	builds, err := listAll(opt.PerPage, func(lo ListOptions) (*[]api.Build, *Response, error) {
+		bo := &BuildListOptions{
+			Event:       constants.EventSchedule,
+			After:       since.Unix() - 1,
+			Before:      until.Add(grace).Unix() + 1,
+			ListOptions: lo,
+		}
+
+		return svc.client.Build.GetAll(ctx, org, repo, bo)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list schedule builds for %s/%s: %w", org, repo, err)
+	}
+
+	// the server records the schedule that
+	// triggered the build in the deploy field
+	bySchedule := make(map[string][]*api.Build)
+
+	for i := range builds {
+		b := &builds[i]
+
+		bySchedule[b.GetDeploy()] = append(bySchedule[b.GetDeploy()], b)
+	}
+
+	var statuses []*ScheduleStatus
+
+	for i := range schedules {
+		s := &schedules[i]
+
+		status := &ScheduleStatus{Schedule: s}
+		statuses = append(statuses, status)
+
+		if s.GetScheduledAt() > 0 {
+			status.LastRun = time.Unix(s.GetScheduledAt(), 0).UTC()
+		}
+
+		c, err := ParseCron(s.GetEntry())
+		if err != nil {
+			status.Err = err
+
+			continue
+		}
+
+		if !s.GetActive() {
+			continue
+		}
+
+		status.NextRun = c.Next(until)
+		if s.GetNextRun() > 0 {
+			status.NextRun = time.Unix(s.GetNextRun(), 0).UTC()
+		}
+
+		status.Runs = scheduleRuns(c, bySchedule[s.GetName()], since, until, grace)
+
+		for _, r := range status.Runs {
+			if r.Build == nil {
+				status.Missed = append(status.Missed, r.Due)
+
+				continue
+			}
+
+			switch r.Build.GetStatus() {
+			case constants.StatusFailure, constants.StatusError, constants.StatusKilled:
+				status.Failed = append(status.Failed, r.Build)
+			}
+		}
+	}
+
+	return statuses, nil
+}
+
+// scheduleRuns returns every run due within the window and matches
+// each with the first build created before the next run is due or
+// the grace period ends, whichever comes first.
+func scheduleRuns(c *Cron, builds []*api.Build, since, until time.Time, grace time.Duration) []ScheduleRun {
+	var runs []ScheduleRun
+
+	// skip runs that are still within the grace period
+	// since the build for them may not exist yet
+	end := until.Add(-grace)
+
+	used := make(map[*api.Build]bool)
+
+	for due := c.Next(since.Add(-time.Nanosecond)); !due.IsZero() && !due.After(end); due = c.Next(due) {
+		deadline := due.Add(grace)
+
+		if next := c.Next(due); !next.IsZero() && next.Before(deadline) {
+			deadline = next
+		}
+
+		run := ScheduleRun{Due: due}
+
+		for _, b := range builds {
+			created := time.Unix(b.GetCreated(), 0)
+
+			if used[b] || created.Before(due) || !created.Before(deadline) {
+				continue
+			}
+
+			used[b] = true
+			run.Build = b
+
+			break
+		}
+
+		runs = append(runs, run)
+	}
+
+	return runs
+}
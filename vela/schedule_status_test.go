@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	api "github.com/go-vela/server/api/types"
+	"github.com/go-vela/server/constants"
+)
+
+func TestSchedule_Status(t *testing.T) {
+	// setup types
+	until := time.Date(2025, time.January, 3, 12, 0, 0, 0, time.UTC)
+	since := until.Add(-72 * time.Hour)
+
+	at := func(day, hour, minute int) int64 {
+		return time.Date(2025, time.January, day, hour, minute, 0, 0, time.UTC).Unix()
+	}
+
+	var gotQuery string
+
+	s := fakeServer(t, func(mux *http.ServeMux) {
+		mux.HandleFunc("GET /api/v1/schedules/github/octocat", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("page") != "1" {
+				_, _ = w.Write([]byte(`[]`))
+
+				return
+			}
+
+			_ = json.NewEncoder(w).Encode([]api.Schedule{
+				{Name: new("nightly"), Entry: new("0 2 * * *"), Active: new(true), Branch: new("main"), ScheduledAt: new(at(3, 2, 3))},
+				{Name: new("hourly"), Entry: new("30 * * * *"), Active: new(false)},
+				{Name: new("broken"), Entry: new("bad"), Active: new(true)},
+				{Name: new("noon"), Entry: new("55 11 * * *"), Active: new(true), NextRun: new(at(4, 11, 55))},
+			})
+		})
+
+		mux.HandleFunc("GET /api/v1/repos/github/octocat/builds", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("page") != "1" {
+				_, _ = w.Write([]byte(`[]`))
+
+				return
+			}
+
+			gotQuery = r.URL.Query().Get("event")
+
+			_ = json.NewEncoder(w).Encode([]api.Build{
+				// the run on the 1st was missed entirely
+				{Number: new(int64(1)), Deploy: new("nightly"), Created: new(at(2, 2, 4)), Status: new(constants.StatusFailure)},
+				{Number: new(int64(2)), Deploy: new("nightly"), Created: new(at(3, 2, 3)), Status: new(constants.StatusSuccess)},
+				{Number: new(int64(3)), Deploy: new("noon"), Created: new(at(1, 11, 56)), Status: new(constants.StatusSuccess)},
+				{Number: new(int64(4)), Deploy: new("noon"), Created: new(at(2, 11, 57)), Status: new(constants.StatusSuccess)},
+			})
+		})
+	})
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	// run test
+	got, err := c.Schedule.Status(t.Context(), "github", "octocat", &ScheduleStatusOptions{Since: since, Until: until})
+	if err != nil {
+		t.Fatalf("Status returned err: %v", err)
+	}
+
+	if gotQuery != constants.EventSchedule {
+		t.Errorf("Status requested builds for event %q, want %q", gotQuery, constants.EventSchedule)
+	}
+
+	if len(got) != 4 {
+		t.Fatalf("Status returned %d statuses, want 4", len(got))
+	}
+
+	nightly := got[0]
+
+	if len(nightly.Runs) != 3 {
+		t.Errorf("Status nightly runs is %d, want 3", len(nightly.Runs))
+	}
+
+	if !reflect.DeepEqual(nightly.Missed, []time.Time{time.Unix(at(1, 2, 0), 0).UTC()}) {
+		t.Errorf("Status nightly missed is %v", nightly.Missed)
+	}
+
+	if len(nightly.Failed) != 1 || nightly.Failed[0].GetNumber() != 1 {
+		t.Errorf("Status nightly failed is %v, want build 1", nightly.Failed)
+	}
+
+	if !nightly.NextRun.Equal(time.Unix(at(4, 2, 0), 0)) {
+		t.Errorf("Status nightly next run is %v", nightly.NextRun)
+	}
+
+	if !nightly.LastRun.Equal(time.Unix(at(3, 2, 3), 0)) {
+		t.Errorf("Status nightly last run is %v", nightly.LastRun)
+	}
+
+	if hourly := got[1]; len(hourly.Runs) != 0 || !hourly.NextRun.IsZero() {
+		t.Errorf("Status should not report runs for inactive schedules")
+	}
+
+	if got[2].Err == nil {
+		t.Errorf("Status should have returned err for invalid entry")
+	}
+
+	// the run on the 3rd is still within the grace period
+	noon := got[3]
+
+	if len(noon.Runs) != 2 || len(noon.Missed) != 0 || len(noon.Failed) != 0 {
+		t.Errorf("Status noon is %d runs, %v missed, %v failed", len(noon.Runs), noon.Missed, noon.Failed)
+	}
+
+	if !noon.NextRun.Equal(time.Unix(at(4, 11, 55), 0)) {
+		t.Errorf("Status noon next run is %v, want server provided next run", noon.NextRun)
+	}
+}
+
+func TestSchedule_Status_Error(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"error":"boom"}`))
+	}))
+	defer s.Close()
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	// run test
+	_, err := c.Schedule.Status(t.Context(), "github", "octocat", nil)
+	if err == nil {
+		t.Errorf("Status should have returned err")
+	}
+}
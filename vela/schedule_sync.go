@@ -0,0 +1,272 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+
+	api "github.com/go-vela/server/api/types"
+)
+
+const (
+	// ScheduleActionCreate defines the action for
+	// a schedule missing from the repo.
+	ScheduleActionCreate = "create"
+
+	// ScheduleActionUpdate defines the action for a
+	// schedule that differs from the manifest.
+	ScheduleActionUpdate = "update"
+
+	// ScheduleActionDelete defines the action for an
+	// unmanaged schedule removed from the repo.
+	ScheduleActionDelete = "delete"
+
+	// ScheduleActionDisable defines the action for an
+	// unmanaged schedule deactivated on the repo.
+	ScheduleActionDisable = "disable"
+
+	// ScheduleActionIgnore defines the action for an
+	// unmanaged schedule left as-is on the repo.
+	ScheduleActionIgnore = "ignore"
+)
+
+// ScheduleManifest represents the desired
+// schedules for a repo, typically kept in git.
+type ScheduleManifest struct {
+	Schedules []ScheduleSpec `json:"schedules" yaml:"schedules"`
+}
+
+// ScheduleSpec represents the desired
+// state of a single schedule.
+type ScheduleSpec struct {
+	Name   string `json:"name"             yaml:"name"`
+	Entry  string `json:"entry"            yaml:"entry"`
+	Branch string `json:"branch,omitempty" yaml:"branch,omitempty"`
+
+	// Whether the schedule is active.
+	//
+	// Default: true
+	Active *bool `json:"active,omitempty" yaml:"active,omitempty"`
+}
+
+// ScheduleSyncOptions specifies the optional parameters
+// to the Schedule.Sync method.
+type ScheduleSyncOptions struct {
+	// Plan the changes without applying them.
+	DryRun bool
+
+	// Action taken for schedules on the repo
+	// that are missing from the manifest.
+	//
+	// Can be: ignore, disable or delete
+	//
+	// Default: ignore
+	Unmanaged string
+}
+
+// ScheduleChange represents a single change
+// needed to reconcile a schedule.
+type ScheduleChange struct {
+	Action string
+	Name   string
+
+	// Schedule as it exists on the repo.
+	Current *api.Schedule
+
+	// Schedule as it is sent to the server.
+	Desired *api.Schedule
+
+	// Error encountered while applying the change.
+	Err error
+}
+
+// SchedulePlan represents the changes needed to
+// reconcile the schedules for a repo.
+type SchedulePlan struct {
+	Changes []*ScheduleChange
+
+	// Whether the changes were applied.
+	Applied bool
+}
+
+// ParseScheduleManifest parses the YAML, or JSON,
+// manifest and validates every schedule in it.
+func ParseScheduleManifest(data []byte) (*ScheduleManifest, error) {
+	m := new(ScheduleManifest)
+
+	err := yaml.Unmarshal(data, m)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse schedule manifest: %w", err)
+	}
+
+	return m, m.Validate()
+}
+
+// Validate checks that every schedule in the manifest
+// has a unique name and a valid cron entry.
+func (m *ScheduleManifest) Validate() error {
+	var errs []error
+
+	names := make(map[string]bool)
+
+	for i, s := range m.Schedules {
+		if len(s.Name) == 0 {
+			errs = append(errs, fmt.Errorf("schedule %d: no name provided", i))
+
+			continue
+		}
+
+		if names[s.Name] {
+			errs = append(errs, fmt.Errorf("schedule %s: duplicate name", s.Name))
+		}
+
+		names[s.Name] = true
+
+		_, err := ParseCron(s.Entry)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("schedule %s: %w", s.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Preview returns the next n times the schedule runs after t.
+func (s *ScheduleSpec) Preview(t time.Time, n int) ([]time.Time, error) {
+	c, err := ParseCron(s.Entry)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.NextN(t, n), nil
+}
+
+// Sync reconciles the schedules for the repo with the manifest,
+// creating and updating schedules to match it. Schedules missing
+// from the manifest are handled based on the Unmanaged option.
+// The manifest is validated before any change is made and the
+// returned plan describes every change, applied or not.
+func (svc *ScheduleService) Sync(ctx context.Context, org, repo string, m *ScheduleManifest, opt *ScheduleSyncOptions) (*SchedulePlan, error) {
+	if m == nil {
+		return nil, fmt.Errorf("no schedule manifest provided")
+	}
+
+	if opt == nil {
+		opt = new(ScheduleSyncOptions)
+	}
+
+	switch opt.Unmanaged {
+	case "", ScheduleActionIgnore, ScheduleActionDisable, ScheduleActionDelete:
+	default:
+		return nil, fmt.Errorf("invalid action for unmanaged schedules: %s", opt.Unmanaged)
+	}
+
+	err := m.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := listAll(0, func(lo ListOptions) (*[]api.Schedule, *Response, error) {
+		return svc.GetAll(ctx, org, repo, &lo)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list schedules for %s/%s: %w", org, repo, err)
+	}
+
+	plan := planSchedules(existing, m, opt.Unmanaged)
+
+	if opt.DryRun {
+		return plan, nil
+	}
+
+	var errs []error
+
+	for _, c := range plan.Changes {
+		switch c.Action {
+		case ScheduleActionCreate:
+			_, _, c.Err = svc.Add(ctx, org, repo, c.Desired)
+		case ScheduleActionUpdate, ScheduleActionDisable:
+			_, _, c.Err = svc.Update(ctx, org, repo, c.Desired)
+		case ScheduleActionDelete:
+			_, _, c.Err = svc.Remove(ctx, org, repo, c.Name)
+		}
+
+		if c.Err != nil {
+			errs = append(errs, fmt.Errorf("unable to %s schedule %s: %w", c.Action, c.Name, c.Err))
+		}
+	}
+
+	plan.Applied = true
+
+	return plan, errors.Join(errs...)
+}
+
+// planSchedules compares the existing schedules with the
+// manifest and returns the changes needed to reconcile them.
+func planSchedules(existing []api.Schedule, m *ScheduleManifest, unmanaged string) *SchedulePlan {
+	plan := new(SchedulePlan)
+
+	current := make(map[string]*api.Schedule)
+
+	for i := range existing {
+		current[existing[i].GetName()] = &existing[i]
+	}
+
+	for _, s := range m.Schedules {
+		active := true
+		if s.Active != nil {
+			active = *s.Active
+		}
+
+		desired := &api.Schedule{
+			Name:   new(s.Name),
+			Entry:  new(s.Entry),
+			Active: new(active),
+		}
+
+		if len(s.Branch) > 0 {
+			desired.Branch = new(s.Branch)
+		}
+
+		cur, ok := current[s.Name]
+		delete(current, s.Name)
+
+		if !ok {
+			plan.Changes = append(plan.Changes, &ScheduleChange{Action: ScheduleActionCreate, Name: s.Name, Desired: desired})
+
+			continue
+		}
+
+		if cur.GetEntry() != s.Entry || cur.GetActive() != active || (len(s.Branch) > 0 && cur.GetBranch() != s.Branch) {
+			plan.Changes = append(plan.Changes, &ScheduleChange{Action: ScheduleActionUpdate, Name: s.Name, Current: cur, Desired: desired})
+		}
+	}
+
+	// handle the schedules missing from the manifest in their original order
+	for i := range existing {
+		cur, ok := current[existing[i].GetName()]
+		if !ok {
+			continue
+		}
+
+		switch unmanaged {
+		case ScheduleActionDelete:
+			plan.Changes = append(plan.Changes, &ScheduleChange{Action: ScheduleActionDelete, Name: cur.GetName(), Current: cur})
+		case ScheduleActionDisable:
+			if !cur.GetActive() {
+				continue
+			}
+
+			desired := &api.Schedule{Name: cur.Name, Active: new(false)}
+
+			plan.Changes = append(plan.Changes, &ScheduleChange{Action: ScheduleActionDisable, Name: cur.GetName(), Current: cur, Desired: desired})
+		}
+	}
+
+	return plan
+}
@@ -0,0 +1,223 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testScheduleManifest = `
+schedules:
+  - name: nightly
+    entry: "0 2 * * *"
+  - name: weekly
+    entry: "@weekly"
+    branch: release
+  - name: hourly
+    entry: "0 * * * *"
+    active: false
+`
+
+func TestSchedule_ParseScheduleManifest(t *testing.T) {
+	// run test
+	got, err := ParseScheduleManifest([]byte(testScheduleManifest))
+	if err != nil {
+		t.Errorf("ParseScheduleManifest returned err: %v", err)
+	}
+
+	if len(got.Schedules) != 3 || got.Schedules[1].Branch != "release" || *got.Schedules[2].Active {
+		t.Errorf("ParseScheduleManifest is %v", got)
+	}
+
+	_, err = ParseScheduleManifest([]byte("schedules:\n  - name: foo\n    entry: bad\n  - name: foo\n    entry: '@daily'\n  - entry: '@daily'\n"))
+	if err == nil {
+		t.Errorf("ParseScheduleManifest should have returned err")
+	}
+
+	_, err = ParseScheduleManifest([]byte("schedules: {"))
+	if err == nil {
+		t.Errorf("ParseScheduleManifest should have returned err")
+	}
+}
+
+func TestSchedule_ScheduleSpec_Preview(t *testing.T) {
+	// setup types
+	s := &ScheduleSpec{Name: "nightly", Entry: "0 2 * * *"}
+	now := time.Date(2025, time.January, 1, 10, 0, 0, 0, time.UTC)
+
+	// run test
+	got, err := s.Preview(now, 2)
+	if err != nil {
+		t.Errorf("Preview returned err: %v", err)
+	}
+
+	want := []time.Time{
+		time.Date(2025, time.January, 2, 2, 0, 0, 0, time.UTC),
+		time.Date(2025, time.January, 3, 2, 0, 0, 0, time.UTC),
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Preview is %v, want %v", got, want)
+	}
+
+	_, err = (&ScheduleSpec{Entry: "bad"}).Preview(now, 2)
+	if err == nil {
+		t.Errorf("Preview should have returned err")
+	}
+}
+
+func TestSchedule_Sync(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls []string
+	)
+
+	// the mock server returns the "foo" and "bar"
+	// schedules, both active on main every week
+	s := mockServer(t, func(r *http.Request) {
+		if r.Method == http.MethodGet {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		calls = append(calls, r.Method+" "+r.URL.Path)
+	})
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	// setup tests
+	tests := []struct {
+		name      string
+		schedules []ScheduleSpec
+		opt       *ScheduleSyncOptions
+		want      []string
+		wantCalls []string
+	}{
+		{
+			name: "unchanged",
+			schedules: []ScheduleSpec{
+				{Name: "foo", Entry: "@weekly"},
+				{Name: "bar", Entry: "@weekly", Branch: "main"},
+			},
+			want:      []string{},
+			wantCalls: []string{},
+		},
+		{
+			name: "update and create",
+			schedules: []ScheduleSpec{
+				{Name: "foo", Entry: "@weekly", Branch: "release"},
+				{Name: "bar", Entry: "@weekly", Active: new(false)},
+				{Name: "nightly", Entry: "0 2 * * *"},
+			},
+			want: []string{
+				ScheduleActionUpdate + " foo",
+				ScheduleActionUpdate + " bar",
+				ScheduleActionCreate + " nightly",
+			},
+			wantCalls: []string{
+				"PUT /api/v1/schedules/github/octocat/foo",
+				"PUT /api/v1/schedules/github/octocat/bar",
+				"POST /api/v1/schedules/github/octocat",
+			},
+		},
+		{
+			name: "dry run",
+			schedules: []ScheduleSpec{
+				{Name: "foo", Entry: "0 2 * * *"},
+			},
+			opt:       &ScheduleSyncOptions{DryRun: true, Unmanaged: ScheduleActionDelete},
+			want:      []string{ScheduleActionUpdate + " foo", ScheduleActionDelete + " bar"},
+			wantCalls: []string{},
+		},
+		{
+			name: "ignore unmanaged",
+			schedules: []ScheduleSpec{
+				{Name: "foo", Entry: "@weekly"},
+			},
+			want:      []string{},
+			wantCalls: []string{},
+		},
+		{
+			name: "disable unmanaged",
+			schedules: []ScheduleSpec{
+				{Name: "foo", Entry: "@weekly"},
+			},
+			opt:       &ScheduleSyncOptions{Unmanaged: ScheduleActionDisable},
+			want:      []string{ScheduleActionDisable + " bar"},
+			wantCalls: []string{"PUT /api/v1/schedules/github/octocat/bar"},
+		},
+		{
+			name: "delete unmanaged",
+			schedules: []ScheduleSpec{
+				{Name: "nightly", Entry: "0 2 * * *"},
+			},
+			opt:  &ScheduleSyncOptions{Unmanaged: ScheduleActionDelete},
+			want: []string{ScheduleActionCreate + " nightly", ScheduleActionDelete + " foo", ScheduleActionDelete + " bar"},
+			wantCalls: []string{
+				"POST /api/v1/schedules/github/octocat",
+				"DELETE /api/v1/schedules/github/octocat/foo",
+				"DELETE /api/v1/schedules/github/octocat/bar",
+			},
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mu.Lock()
+			calls = []string{}
+			mu.Unlock()
+
+			got, err := c.Schedule.Sync(t.Context(), "github", "octocat", &ScheduleManifest{Schedules: test.schedules}, test.opt)
+			if err != nil {
+				t.Errorf("Sync returned err: %v", err)
+			}
+
+			if dryRun := test.opt != nil && test.opt.DryRun; got.Applied == dryRun {
+				t.Errorf("Sync applied the plan is %v, want %v", got.Applied, !dryRun)
+			}
+
+			actions := []string{}
+			for _, c := range got.Changes {
+				actions = append(actions, c.Action+" "+c.Name)
+			}
+
+			if !reflect.DeepEqual(actions, test.want) {
+				t.Errorf("Sync plan is %v, want %v", actions, test.want)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if !reflect.DeepEqual(calls, test.wantCalls) {
+				t.Errorf("Sync calls are %v, want %v", calls, test.wantCalls)
+			}
+		})
+	}
+}
+
+func TestSchedule_Sync_Invalid(t *testing.T) {
+	c, _ := NewClient("http://localhost:8080", "", nil)
+
+	// run test
+	_, err := c.Schedule.Sync(t.Context(), "github", "octocat", &ScheduleManifest{Schedules: []ScheduleSpec{{Name: "foo", Entry: "bad"}}}, nil)
+	if err == nil {
+		t.Errorf("Sync should have returned err")
+	}
+
+	_, err = c.Schedule.Sync(t.Context(), "github", "octocat", &ScheduleManifest{}, &ScheduleSyncOptions{Unmanaged: "foo"})
+	if err == nil {
+		t.Errorf("Sync should have returned err")
+	}
+
+	_, err = c.Schedule.Sync(t.Context(), "github", "octocat", nil, nil)
+	if err == nil {
+		t.Errorf("Sync should have returned err")
+	}
+}
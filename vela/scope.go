@@ -0,0 +1,418 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"context"
+
+	api "github.com/go-vela/server/api/types"
+	"github.com/go-vela/server/compiler/types/yaml"
+	"github.com/go-vela/server/constants"
+)
+
+// RepoScope provides the services for a single repository,
+// filling in the org and repo for each request.
+type RepoScope struct {
+	client *Client
+	org    string
+	repo   string
+}
+
+// OrgScope provides the services for a single org,
+// filling in the org for each request.
+type OrgScope struct {
+	client *Client
+	org    string
+}
+
+// ForRepo returns the services scoped to the repository.
+func (c *Client) ForRepo(org, repo string) *RepoScope {
+	return &RepoScope{client: c, org: org, repo: repo}
+}
+
+// ForOrg returns the services scoped to the org.
+func (c *Client) ForOrg(org string) *OrgScope {
+	return &OrgScope{client: c, org: org}
+}
+
+// Org returns the org for the scope.
+func (s *RepoScope) Org() string {
+	return s.org
+}
+
+// Name returns the name of the repository for the scope.
+func (s *RepoScope) Name() string {
+	return s.repo
+}
+
+// FullName returns the org and name of the repository for the scope.
+func (s *RepoScope) FullName() string {
+	return s.org + "/" + s.repo
+}
+
+// Get returns the repository.
+func (s *RepoScope) Get(ctx context.Context) (*api.Repo, *Response, error) {
+	return s.client.Repo.Get(ctx, s.org, s.repo)
+}
+
+// Update modifies the repository with the provided details.
+func (s *RepoScope) Update(ctx context.Context, r *api.Repo) (*api.Repo, *Response, error) {
+	return s.client.Repo.Update(ctx, s.org, s.repo, r)
+}
+
+// Remove deletes the repository.
+func (s *RepoScope) Remove(ctx context.Context) (*string, *Response, error) {
+	return s.client.Repo.Remove(ctx, s.org, s.repo)
+}
+
+// Repair repairs the hooks for the repository.
+func (s *RepoScope) Repair(ctx context.Context) (*string, *Response, error) {
+	return s.client.Repo.Repair(ctx, s.org, s.repo)
+}
+
+// Chown changes ownership of the repository to the current user.
+func (s *RepoScope) Chown(ctx context.Context) (*string, *Response, error) {
+	return s.client.Repo.Chown(ctx, s.org, s.repo)
+}
+
+// Builds returns the builds for the repository.
+func (s *RepoScope) Builds() *RepoBuilds {
+	return &RepoBuilds{scope: s}
+}
+
+// Deployments returns the deployments for the repository.
+func (s *RepoScope) Deployments() *RepoDeployments {
+	return &RepoDeployments{scope: s}
+}
+
+// Hooks returns the hooks for the repository.
+func (s *RepoScope) Hooks() *RepoHooks {
+	return &RepoHooks{scope: s}
+}
+
+// Pipelines returns the pipelines for the repository.
+func (s *RepoScope) Pipelines() *RepoPipelines {
+	return &RepoPipelines{scope: s}
+}
+
+// Schedules returns the schedules for the repository.
+func (s *RepoScope) Schedules() *RepoSchedules {
+	return &RepoSchedules{scope: s}
+}
+
+// Secrets returns the repo secrets for the repository
+// stored in the native engine.
+func (s *RepoScope) Secrets() *SecretScope {
+	return &SecretScope{
+		client: s.client,
+		engine: constants.DriverNative,
+		sType:  constants.SecretRepo,
+		org:    s.org,
+		name:   s.repo,
+	}
+}
+
+// Name returns the name of the org for the scope.
+func (s *OrgScope) Name() string {
+	return s.org
+}
+
+// Repo returns the services scoped to the repository in the org.
+func (s *OrgScope) Repo(repo string) *RepoScope {
+	return s.client.ForRepo(s.org, repo)
+}
+
+// Secrets returns the org secrets for the org
+// stored in the native engine.
+func (s *OrgScope) Secrets() *SecretScope {
+	return &SecretScope{
+		client: s.client,
+		engine: constants.DriverNative,
+		sType:  constants.SecretOrg,
+		org:    s.org,
+		name:   "*",
+	}
+}
+
+// SharedSecrets returns the shared secrets for the
+// team in the org stored in the native engine.
+func (s *OrgScope) SharedSecrets(team string) *SecretScope {
+	return &SecretScope{
+		client: s.client,
+		engine: constants.DriverNative,
+		sType:  constants.SecretShared,
+		org:    s.org,
+		name:   team,
+	}
+}
+
+// RepoBuilds provides the builds for a repository.
+type RepoBuilds struct {
+	scope *RepoScope
+}
+
+// Get returns the provided build.
+func (b *RepoBuilds) Get(ctx context.Context, build int64) (*api.Build, *Response, error) {
+	return b.scope.client.Build.Get(ctx, b.scope.org, b.scope.repo, build)
+}
+
+// GetAll returns a list of all builds.
+func (b *RepoBuilds) GetAll(ctx context.Context, opt *BuildListOptions) (*[]api.Build, *Response, error) {
+	return b.scope.client.Build.GetAll(ctx, b.scope.org, b.scope.repo, opt)
+}
+
+// GetLogs returns the logs for the provided build.
+func (b *RepoBuilds) GetLogs(ctx context.Context, build int64, opt *ListOptions) (*[]api.Log, *Response, error) {
+	return b.scope.client.Build.GetLogs(ctx, b.scope.org, b.scope.repo, build, opt)
+}
+
+// Add constructs a build with the provided details
+// for the repository, without modifying the build.
+func (b *RepoBuilds) Add(ctx context.Context, build *api.Build) (*api.Build, *Response, error) {
+	return b.scope.client.Build.Add(ctx, b.scope.build(build))
+}
+
+// Update modifies a build with the provided details
+// for the repository, without modifying the build.
+func (b *RepoBuilds) Update(ctx context.Context, build *api.Build) (*api.Build, *Response, error) {
+	return b.scope.client.Build.Update(ctx, b.scope.build(build))
+}
+
+// Remove deletes the provided build.
+func (b *RepoBuilds) Remove(ctx context.Context, build int64) (*string, *Response, error) {
+	return b.scope.client.Build.remove(ctx, b.scope.org, b.scope.repo, build)
+}
+
+// Restart takes the build provided and restarts it.
+func (b *RepoBuilds) Restart(ctx context.Context, build int64) (*api.Build, *Response, error) {
+	return b.scope.client.Build.Restart(ctx, b.scope.org, b.scope.repo, build)
+}
+
+// Cancel takes the build provided and cancels it.
+func (b *RepoBuilds) Cancel(ctx context.Context, build int64) (*api.Build, *Response, error) {
+	return b.scope.client.Build.Cancel(ctx, b.scope.org, b.scope.repo, build)
+}
+
+// Approve takes the build provided and approves it.
+func (b *RepoBuilds) Approve(ctx context.Context, build int64) (*Response, error) {
+	return b.scope.client.Build.Approve(ctx, b.scope.org, b.scope.repo, build)
+}
+
+// build returns a copy of the build with the repository
+// set to the org and repo for the scope.
+func (s *RepoScope) build(b *api.Build) *api.Build {
+	if b == nil {
+		b = new(api.Build)
+	}
+
+	cp := *b
+
+	r := new(api.Repo)
+	if b.Repo != nil {
+		*r = *b.Repo
+	}
+
+	r.SetOrg(s.org)
+	r.SetName(s.repo)
+	r.SetFullName(s.FullName())
+
+	cp.SetRepo(r)
+
+	return &cp
+}
+
+// RepoDeployments provides the deployments for a repository.
+type RepoDeployments struct {
+	scope *RepoScope
+}
+
+// Get returns the provided deployment.
+func (d *RepoDeployments) Get(ctx context.Context, deployment int64) (*api.Deployment, *Response, error) {
+	return d.scope.client.Deployment.Get(ctx, d.scope.org, d.scope.repo, deployment)
+}
+
+// GetAll returns a list of all deployments.
+func (d *RepoDeployments) GetAll(ctx context.Context, opt *ListOptions) (*[]api.Deployment, *Response, error) {
+	return d.scope.client.Deployment.GetAll(ctx, d.scope.org, d.scope.repo, opt)
+}
+
+// Add constructs a deployment with the provided details.
+func (d *RepoDeployments) Add(ctx context.Context, deployment *api.Deployment) (*api.Deployment, *Response, error) {
+	return d.scope.client.Deployment.Add(ctx, d.scope.org, d.scope.repo, deployment)
+}
+
+// RepoHooks provides the hooks for a repository.
+type RepoHooks struct {
+	scope *RepoScope
+}
+
+// Get returns the provided hook.
+func (h *RepoHooks) Get(ctx context.Context, hook int64) (*api.Hook, *Response, error) {
+	return h.scope.client.Hook.Get(ctx, h.scope.org, h.scope.repo, hook)
+}
+
+// GetAll returns a list of all hooks.
+func (h *RepoHooks) GetAll(ctx context.Context, opt *ListOptions) (*[]api.Hook, *Response, error) {
+	return h.scope.client.Hook.GetAll(ctx, h.scope.org, h.scope.repo, opt)
+}
+
+// Add constructs a hook with the provided details.
+func (h *RepoHooks) Add(ctx context.Context, hook *api.Hook) (*api.Hook, *Response, error) {
+	return h.scope.client.Hook.Add(ctx, h.scope.org, h.scope.repo, hook)
+}
+
+// Update modifies a hook with the provided details.
+func (h *RepoHooks) Update(ctx context.Context, hook *api.Hook) (*api.Hook, *Response, error) {
+	return h.scope.client.Hook.Update(ctx, h.scope.org, h.scope.repo, hook)
+}
+
+// Remove deletes the provided hook.
+func (h *RepoHooks) Remove(ctx context.Context, hook int64) (*string, *Response, error) {
+	return h.scope.client.Hook.Remove(ctx, h.scope.org, h.scope.repo, hook)
+}
+
+// RepoPipelines provides the pipelines for a repository.
+type RepoPipelines struct {
+	scope *RepoScope
+}
+
+// Get returns the pipeline for the provided ref.
+func (p *RepoPipelines) Get(ctx context.Context, ref string) (*api.Pipeline, *Response, error) {
+	return p.scope.client.Pipeline.Get(ctx, p.scope.org, p.scope.repo, ref)
+}
+
+// GetAll returns a list of all pipelines.
+func (p *RepoPipelines) GetAll(ctx context.Context, opt *ListOptions) (*[]api.Pipeline, *Response, error) {
+	return p.scope.client.Pipeline.GetAll(ctx, p.scope.org, p.scope.repo, opt)
+}
+
+// Add constructs a pipeline with the provided details.
+func (p *RepoPipelines) Add(ctx context.Context, pipeline *api.Pipeline) (*api.Pipeline, *Response, error) {
+	return p.scope.client.Pipeline.Add(ctx, p.scope.org, p.scope.repo, pipeline)
+}
+
+// Update modifies a pipeline with the provided details.
+func (p *RepoPipelines) Update(ctx context.Context, pipeline *api.Pipeline) (*api.Pipeline, *Response, error) {
+	return p.scope.client.Pipeline.Update(ctx, p.scope.org, p.scope.repo, pipeline)
+}
+
+// Remove deletes the provided pipeline.
+func (p *RepoPipelines) Remove(ctx context.Context, pipeline string) (*string, *Response, error) {
+	return p.scope.client.Pipeline.Remove(ctx, p.scope.org, p.scope.repo, pipeline)
+}
+
+// Compile returns the compiled pipeline for the provided ref.
+func (p *RepoPipelines) Compile(ctx context.Context, ref string, opt *PipelineOptions) (*yaml.Build, *Response, error) {
+	return p.scope.client.Pipeline.Compile(ctx, p.scope.org, p.scope.repo, ref, opt)
+}
+
+// Expand returns the pipeline for the provided ref with templates expanded.
+func (p *RepoPipelines) Expand(ctx context.Context, ref string, opt *PipelineOptions) (*yaml.Build, *Response, error) {
+	return p.scope.client.Pipeline.Expand(ctx, p.scope.org, p.scope.repo, ref, opt)
+}
+
+// Templates returns the templates for the pipeline for the provided ref.
+func (p *RepoPipelines) Templates(ctx context.Context, ref string, opt *PipelineOptions) (map[string]*yaml.Template, *Response, error) {
+	return p.scope.client.Pipeline.Templates(ctx, p.scope.org, p.scope.repo, ref, opt)
+}
+
+// Validate returns the validation result for the pipeline for the provided ref.
+func (p *RepoPipelines) Validate(ctx context.Context, ref string, opt *PipelineOptions) (*string, *Response, error) {
+	return p.scope.client.Pipeline.Validate(ctx, p.scope.org, p.scope.repo, ref, opt)
+}
+
+// RepoSchedules provides the schedules for a repository.
+type RepoSchedules struct {
+	scope *RepoScope
+}
+
+// Get returns the provided schedule.
+func (s *RepoSchedules) Get(ctx context.Context, schedule string) (*api.Schedule, *Response, error) {
+	return s.scope.client.Schedule.Get(ctx, s.scope.org, s.scope.repo, schedule)
+}
+
+// GetAll returns a list of all schedules.
+func (s *RepoSchedules) GetAll(ctx context.Context, opt *ListOptions) (*[]api.Schedule, *Response, error) {
+	return s.scope.client.Schedule.GetAll(ctx, s.scope.org, s.scope.repo, opt)
+}
+
+// Add constructs a schedule with the provided details.
+func (s *RepoSchedules) Add(ctx context.Context, schedule *api.Schedule) (*api.Schedule, *Response, error) {
+	return s.scope.client.Schedule.Add(ctx, s.scope.org, s.scope.repo, schedule)
+}
+
+// Update modifies a schedule with the provided details.
+func (s *RepoSchedules) Update(ctx context.Context, schedule *api.Schedule) (*api.Schedule, *Response, error) {
+	return s.scope.client.Schedule.Update(ctx, s.scope.org, s.scope.repo, schedule)
+}
+
+// Remove deletes the provided schedule.
+func (s *RepoSchedules) Remove(ctx context.Context, schedule string) (*string, *Response, error) {
+	return s.scope.client.Schedule.Remove(ctx, s.scope.org, s.scope.repo, schedule)
+}
+
+// Status returns the status of the schedules.
+func (s *RepoSchedules) Status(ctx context.Context, opt *ScheduleStatusOptions) ([]*ScheduleStatus, error) {
+	return s.scope.client.Schedule.Status(ctx, s.scope.org, s.scope.repo, opt)
+}
+
+// Sync reconciles the schedules with the manifest.
+func (s *RepoSchedules) Sync(ctx context.Context, m *ScheduleManifest, opt *ScheduleSyncOptions) (*SchedulePlan, error) {
+	return s.scope.client.Schedule.Sync(ctx, s.scope.org, s.scope.repo, m, opt)
+}
+
+// SecretScope provides the secrets of one type for an org,
+// repository or team, stored in a single engine.
+type SecretScope struct {
+	client *Client
+	engine string
+	sType  string
+	org    string
+	name   string
+}
+
+// Engine returns the secrets in the same
+// location stored in the provided engine.
+func (s *SecretScope) Engine(engine string) *SecretScope {
+	cp := *s
+	cp.engine = engine
+
+	return &cp
+}
+
+// Get returns the provided secret.
+func (s *SecretScope) Get(ctx context.Context, secret string) (*api.Secret, *Response, error) {
+	return s.client.Secret.Get(ctx, s.engine, s.sType, s.org, s.name, secret)
+}
+
+// GetAll returns a list of all secrets.
+func (s *SecretScope) GetAll(ctx context.Context, opt *ListOptions) (*[]api.Secret, *Response, error) {
+	return s.client.Secret.GetAll(ctx, s.engine, s.sType, s.org, s.name, opt)
+}
+
+// Add constructs a secret with the provided details.
+func (s *SecretScope) Add(ctx context.Context, secret *api.Secret) (*api.Secret, *Response, error) {
+	return s.client.Secret.Add(ctx, s.engine, s.sType, s.org, s.name, secret)
+}
+
+// Update modifies a secret with the provided details.
+func (s *SecretScope) Update(ctx context.Context, secret *api.Secret) (*api.Secret, *Response, error) {
+	return s.client.Secret.Update(ctx, s.engine, s.sType, s.org, s.name, secret)
+}
+
+// Remove deletes the provided secret.
+func (s *SecretScope) Remove(ctx context.Context, secret string) (*string, *Response, error) {
+	return s.client.Secret.Remove(ctx, s.engine, s.sType, s.org, s.name, secret)
+}
+
+// AddWithSource constructs a secret with the provided
+// details and the value read from the source.
+func (s *SecretScope) AddWithSource(ctx context.Context, secret *api.Secret, src SecretValueSource) (*api.Secret, *Response, error) {
+	return s.client.Secret.AddWithSource(ctx, s.engine, s.sType, s.org, s.name, secret, src)
+}
+
+// UpdateWithSource modifies a secret with the provided
+// details and the value read from the source.
+func (s *SecretScope) UpdateWithSource(ctx context.Context, secret *api.Secret, src SecretValueSource) (*api.Secret, *Response, error) {
+	return s.client.Secret.UpdateWithSource(ctx, s.engine, s.sType, s.org, s.name, secret, src)
+}
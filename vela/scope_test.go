@@ -0,0 +1,208 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	api "github.com/go-vela/server/api/types"
+)
+
+// recordServer returns a server recording the method and path of the
+// last request, along with the decoded body for requests sending one.
+func recordServer(t *testing.T, got *string, body *map[string]any) *httptest.Server {
+	t.Helper()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*got = r.Method + " " + r.URL.Path
+
+		*body = nil
+		_ = json.NewDecoder(r.Body).Decode(body)
+
+		_, _ = w.Write([]byte(`{}`))
+	}))
+
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func TestScope_RepoScope(t *testing.T) {
+	// setup types
+	var (
+		got  string
+		body map[string]any
+	)
+
+	s := recordServer(t, &got, &body)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	r := c.ForRepo("github", "octocat")
+
+	tests := []struct {
+		name string
+		call func(ctx context.Context) error
+		want string
+	}{
+		{
+			name: "repo",
+			call: func(ctx context.Context) error { _, _, err := r.Get(ctx); return err },
+			want: "GET /api/v1/repos/github/octocat",
+		},
+		{
+			name: "repair",
+			call: func(ctx context.Context) error { _, _, err := r.Repair(ctx); return err },
+			want: "PATCH /api/v1/repos/github/octocat/repair",
+		},
+		{
+			name: "build",
+			call: func(ctx context.Context) error { _, _, err := r.Builds().Get(ctx, 1); return err },
+			want: "GET /api/v1/repos/github/octocat/builds/1",
+		},
+		{
+			name: "remove build",
+			call: func(ctx context.Context) error { _, _, err := r.Builds().Remove(ctx, 1); return err },
+			want: "DELETE /api/v1/repos/github/octocat/builds/1",
+		},
+		{
+			name: "add build",
+			call: func(ctx context.Context) error { _, _, err := r.Builds().Add(ctx, new(api.Build)); return err },
+			want: "POST /api/v1/repos/github/octocat/builds",
+		},
+		{
+			name: "deployments",
+			call: func(ctx context.Context) error { _, _, err := r.Deployments().GetAll(ctx, nil); return err },
+			want: "GET /api/v1/deployments/github/octocat",
+		},
+		{
+			name: "hook",
+			call: func(ctx context.Context) error { _, _, err := r.Hooks().Remove(ctx, 1); return err },
+			want: "DELETE /api/v1/hooks/github/octocat/1",
+		},
+		{
+			name: "pipeline",
+			call: func(ctx context.Context) error { _, _, err := r.Pipelines().Get(ctx, "main"); return err },
+			want: "GET /api/v1/pipelines/github/octocat/main",
+		},
+		{
+			name: "schedules",
+			call: func(ctx context.Context) error { _, _, err := r.Schedules().GetAll(ctx, nil); return err },
+			want: "GET /api/v1/schedules/github/octocat",
+		},
+		{
+			name: "secret",
+			call: func(ctx context.Context) error { _, _, err := r.Secrets().Add(ctx, new(api.Secret)); return err },
+			want: "POST /api/v1/secrets/native/repo/github/octocat",
+		},
+		{
+			name: "secret engine",
+			call: func(ctx context.Context) error { _, _, err := r.Secrets().Engine("vault").Get(ctx, "foo"); return err },
+			want: "GET /api/v1/secrets/vault/repo/github/octocat/foo",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// run test
+			err := test.call(t.Context())
+			if err != nil {
+				t.Errorf("%s returned err: %v", test.name, err)
+			}
+
+			if got != test.want {
+				t.Errorf("%s sent %s, want %s", test.name, got, test.want)
+			}
+		})
+	}
+}
+
+func TestScope_RepoScope_Build(t *testing.T) {
+	// setup types
+	var (
+		got  string
+		body map[string]any
+	)
+
+	s := recordServer(t, &got, &body)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	b := new(api.Build)
+	b.SetNumber(1)
+	b.SetRepo(&api.Repo{Org: new("other"), Name: new("repo"), Active: new(true)})
+
+	// run test
+	_, _, err := c.ForRepo("github", "octocat").Builds().Update(t.Context(), b)
+	if err != nil {
+		t.Errorf("Update returned err: %v", err)
+	}
+
+	if want := "PUT /api/v1/repos/github/octocat/builds/1"; got != want {
+		t.Errorf("Update sent %s, want %s", got, want)
+	}
+
+	repo, _ := body["repo"].(map[string]any)
+	if repo["org"] != "github" || repo["name"] != "octocat" || repo["active"] != true {
+		t.Errorf("Update sent repo %v, want the scoped repo", repo)
+	}
+
+	// the provided build is not modified
+	if b.GetRepo().GetOrg() != "other" {
+		t.Errorf("Update should not have modified the build")
+	}
+}
+
+func TestScope_OrgScope(t *testing.T) {
+	// setup types
+	var (
+		got  string
+		body map[string]any
+	)
+
+	s := recordServer(t, &got, &body)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	o := c.ForOrg("github")
+
+	tests := []struct {
+		name string
+		call func(ctx context.Context) error
+		want string
+	}{
+		{
+			name: "repo",
+			call: func(ctx context.Context) error { _, _, err := o.Repo("octocat").Get(ctx); return err },
+			want: "GET /api/v1/repos/github/octocat",
+		},
+		{
+			name: "secrets",
+			call: func(ctx context.Context) error { _, _, err := o.Secrets().GetAll(ctx, nil); return err },
+			want: "GET /api/v1/secrets/native/org/github/*",
+		},
+		{
+			name: "shared secret",
+			call: func(ctx context.Context) error { _, _, err := o.SharedSecrets("ops").Remove(ctx, "foo"); return err },
+			want: "DELETE /api/v1/secrets/native/shared/github/ops/foo",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// run test
+			err := test.call(t.Context())
+			if err != nil {
+				t.Errorf("%s returned err: %v", test.name, err)
+			}
+
+			if got != test.want {
+				t.Errorf("%s sent %s, want %s", test.name, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,341 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	api "github.com/go-vela/server/api/types"
+	"github.com/go-vela/server/compiler/types/yaml"
+	"github.com/go-vela/server/constants"
+)
+
+// SecretAuditOptions specifies the parameters to the
+// Secret.Audit method.
+type SecretAuditOptions struct {
+	// Org to audit secrets for.
+	Org string
+
+	// Secret engine to audit.
+	//
+	// Default: native
+	Engine string
+
+	// Teams to include shared secrets for.
+	Teams []string
+
+	// Options used when compiling the pipeline for each repo.
+	// The ref compiled is always the default branch of the repo.
+	Pipeline *PipelineOptions
+
+	// Number of results to request per page when listing resources.
+	//
+	// Default: 100
+	PerPage int
+}
+
+// SecretReference represents a step in a compiled
+// pipeline that references a secret.
+type SecretReference struct {
+	// Full name of the repo with the pipeline.
+	Repo string
+
+	// Name of the step using the secret.
+	Step string
+
+	// Name the secret is declared with in the pipeline.
+	Name string
+
+	// Secret resolved from the key in the pipeline.
+	Target SecretTarget
+
+	// Events the step is restricted to. Empty when the
+	// step runs for every event.
+	Events []string
+
+	// Whether the step runs commands.
+	Commands bool
+}
+
+// SecretUsage represents an audited secret along
+// with every reference to it.
+type SecretUsage struct {
+	Target     SecretTarget
+	Secret     *api.Secret
+	References []SecretReference
+
+	// Events allowed by the secret that are never
+	// used by any step referencing it.
+	ExcessEvents []string
+
+	// Whether the secret allows commands while no
+	// step referencing it runs commands.
+	ExcessCommand bool
+}
+
+// SecretAuditError represents a repo that
+// could not be audited.
+type SecretAuditError struct {
+	Repo string
+	Err  error
+}
+
+// SecretAuditReport represents the outcome of the
+// Secret.Audit method.
+type SecretAuditReport struct {
+	// Every secret that was audited.
+	Secrets []*SecretUsage
+
+	// Secrets without any references.
+	Unused []*SecretUsage
+
+	// References to secrets that do not exist.
+	Dangling []SecretReference
+
+	// References to secrets outside of the org, repos and teams
+	// that were audited, such as secrets for another org or for
+	// a team not included in the options. These secrets are not
+	// looked up, so they may or may not exist.
+	Unverified []SecretReference
+
+	// Secrets allowing more events or commands than
+	// the steps referencing them require.
+	Overbroad []*SecretUsage
+
+	// Repos with pipelines that failed to compile.
+	Errors []SecretAuditError
+}
+
+// Audit walks every repo in the org, compiling the pipeline for the
+// default branch of each, and cross-references the secrets used by
+// steps against the org, repo and shared secrets that exist.
+func (svc *SecretService) Audit(ctx context.Context, opt *SecretAuditOptions) (*SecretAuditReport, error) {
+	if opt == nil || len(opt.Org) == 0 {
+		return nil, fmt.Errorf("no org provided for secret audit")
+	}
+
+	engine := opt.Engine
+	if len(engine) == 0 {
+		engine = constants.DriverNative
+	}
+
+	repos, err := listAll(opt.PerPage, func(lo ListOptions) (*[]api.Repo, *Response, error) {
+		return svc.client.Repo.GetAll(ctx, &lo)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list repos: %w", err)
+	}
+
+	// only audit active repos in the requested org
+	repos = slices.DeleteFunc(repos, func(r api.Repo) bool {
+		return r.GetOrg() != opt.Org || !r.GetActive()
+	})
+
+	// collect the locations secrets may be stored
+	targets := []SecretTarget{{Engine: engine, Type: constants.SecretOrg, Org: opt.Org, Name: "*"}}
+
+	for _, r := range repos {
+		targets = append(targets, SecretTarget{Engine: engine, Type: constants.SecretRepo, Org: opt.Org, Name: r.GetName()})
+	}
+
+	for _, team := range opt.Teams {
+		targets = append(targets, SecretTarget{Engine: engine, Type: constants.SecretShared, Org: opt.Org, Name: team})
+	}
+
+	report := new(SecretAuditReport)
+	index := make(map[string]*SecretUsage)
+	audited := make(map[string]bool)
+
+	for _, t := range targets {
+		audited[t.location()] = true
+
+		secrets, err := listAll(opt.PerPage, func(lo ListOptions) (*[]api.Secret, *Response, error) {
+			return svc.GetAll(ctx, t.Engine, t.Type, t.Org, t.Name, &lo)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to list %s secrets for %s/%s: %w", t.Type, t.Org, t.Name, err)
+		}
+
+		for _, s := range secrets {
+			u := &SecretUsage{Target: t, Secret: &s}
+			u.Target.Secret = s.GetName()
+
+			index[u.Target.String()] = u
+			report.Secrets = append(report.Secrets, u)
+		}
+	}
+
+	for _, r := range repos {
+		p, _, err := svc.client.Pipeline.Compile(ctx, r.GetOrg(), r.GetName(), r.GetBranch(), opt.Pipeline)
+		if err != nil {
+			report.Errors = append(report.Errors, SecretAuditError{Repo: r.GetFullName(), Err: err})
+
+			continue
+		}
+
+		for _, ref := range secretReferences(p, r.GetOrg(), r.GetName(), engine) {
+			ref.Repo = r.GetFullName()
+
+			u, ok := index[ref.Target.String()]
+
+			switch {
+			case !ok && !audited[ref.Target.location()]:
+				report.Unverified = append(report.Unverified, ref)
+
+				continue
+			case !ok:
+				report.Dangling = append(report.Dangling, ref)
+
+				continue
+			}
+
+			u.References = append(u.References, ref)
+		}
+	}
+
+	for _, u := range report.Secrets {
+		if len(u.References) == 0 {
+			report.Unused = append(report.Unused, u)
+
+			continue
+		}
+
+		u.ExcessEvents, u.ExcessCommand = excessPermissions(u)
+
+		if len(u.ExcessEvents) > 0 || u.ExcessCommand {
+			report.Overbroad = append(report.Overbroad, u)
+		}
+	}
+
+	return report, nil
+}
+
+// secretReferences returns a reference for every step in the
+// pipeline using a secret from the secrets block.
+func secretReferences(p *yaml.Build, org, repo, engine string) []SecretReference {
+	// collect the secrets declared by the pipeline
+	declared := make(map[string]SecretTarget)
+
+	for _, s := range p.Secrets {
+		// skip secrets provided by an origin plugin
+		if len(s.Origin.Name) > 0 || len(s.Origin.Image) > 0 {
+			continue
+		}
+
+		t, ok := secretTargetFromKey(s, org, repo, engine)
+		if !ok {
+			continue
+		}
+
+		declared[s.Name] = t
+	}
+
+	steps := slices.Clone(p.Steps)
+
+	for _, stage := range p.Stages {
+		steps = append(steps, stage.Steps...)
+	}
+
+	var refs []SecretReference
+
+	for _, step := range steps {
+		for _, s := range step.Secrets {
+			t, ok := declared[s.Source]
+			if !ok {
+				continue
+			}
+
+			refs = append(refs, SecretReference{
+				Step:     step.Name,
+				Name:     s.Source,
+				Target:   t,
+				Events:   step.Ruleset.If.Event,
+				Commands: len(step.Commands) > 0,
+			})
+		}
+	}
+
+	return refs
+}
+
+// secretTargetFromKey resolves the secret a pipeline secret points to
+// using the key formats supported for each secret type.
+func secretTargetFromKey(s *yaml.Secret, org, repo, engine string) (SecretTarget, bool) {
+	t := SecretTarget{Engine: s.Engine, Type: s.Type, Org: org}
+
+	if len(t.Engine) == 0 {
+		t.Engine = engine
+	}
+
+	if len(t.Type) == 0 {
+		t.Type = constants.SecretRepo
+	}
+
+	key := s.Key
+	if len(key) == 0 {
+		key = s.Name
+	}
+
+	parts := strings.Split(key, "/")
+
+	switch {
+	case t.Type == constants.SecretRepo && len(parts) == 1:
+		t.Name, t.Secret = repo, parts[0]
+	case t.Type == constants.SecretRepo && len(parts) == 3:
+		t.Org, t.Name, t.Secret = parts[0], parts[1], parts[2]
+	case t.Type == constants.SecretOrg && len(parts) == 2:
+		t.Org, t.Name, t.Secret = parts[0], "*", parts[1]
+	case t.Type == constants.SecretShared && len(parts) == 3:
+		t.Org, t.Name, t.Secret = parts[0], parts[1], parts[2]
+	default:
+		return t, false
+	}
+
+	return t, true
+}
+
+// location returns the path of the secrets
+// the target is stored with, without the secret.
+func (t SecretTarget) location() string {
+	return fmt.Sprintf("%s/%s/%s/%s", t.engine(), t.Type, t.Org, t.Name)
+}
+
+// excessPermissions returns the events and command access allowed by
+// the secret that none of the steps referencing it require.
+func excessPermissions(u *SecretUsage) ([]string, bool) {
+	commands := false
+	unrestricted := false
+
+	var events []string
+
+	for _, ref := range u.References {
+		commands = commands || ref.Commands
+
+		if len(ref.Events) == 0 {
+			unrestricted = true
+		}
+
+		events = append(events, ref.Events...)
+	}
+
+	excessCommand := u.Secret.GetAllowCommand() && !commands
+
+	// a step running for every event needs every event
+	if unrestricted || u.Secret.GetAllowEvents() == nil {
+		return nil, excessCommand
+	}
+
+	needed, err := api.NewEventsFromSlice(events)
+	if err != nil {
+		// rulesets may use patterns which can't be
+		// narrowed down to a specific set of events
+		return nil, excessCommand
+	}
+
+	excess := u.Secret.GetAllowEvents().ToDatabase() &^ needed.ToDatabase()
+
+	return api.NewEventsFromMask(excess).List(), excessCommand
+}
@@ -0,0 +1,271 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	api "github.com/go-vela/server/api/types"
+	"github.com/go-vela/server/compiler/types/yaml"
+	"github.com/go-vela/server/constants"
+)
+
+const testAuditPipeline = `---
+version: "1"
+
+secrets:
+  - name: docker_password
+    key: github/docker_password
+    engine: native
+    type: org
+
+  - name: deploy_key
+    key: deploy_key
+    type: repo
+
+  - name: missing
+    key: github/missing
+    type: org
+
+  - name: team_key
+    key: github/ops/team_key
+    type: shared
+
+  - name: other_org
+    key: other/other_org
+    type: org
+
+  - name: other_repo
+    key: github/hello-world/other_repo
+    type: repo
+
+  - name: vault_token
+    origin:
+      name: vault
+      image: target/vela-vault
+
+steps:
+  - name: build
+    image: golang:latest
+    commands:
+      - go build
+    secrets: [ docker_password, missing ]
+    ruleset:
+      event: [ push, tag ]
+
+  - name: publish
+    image: target/vela-docker
+    secrets:
+      - source: docker_password
+        target: docker_password
+    ruleset:
+      event: [ push ]
+
+stages:
+  deploy:
+    steps:
+      - name: deploy
+        image: target/vela-deploy
+        secrets: [ deploy_key, team_key, other_org, other_repo ]
+`
+
+// testAuditRoutes registers the routes for the org being audited.
+func testAuditRoutes(t *testing.T, mux *http.ServeMux) {
+	t.Helper()
+
+	mux.HandleFunc("GET /api/v1/repos", func(w http.ResponseWriter, r *http.Request) {
+		// serve a single page of results
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = w.Write([]byte(`[]`))
+
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode([]api.Repo{
+			{Org: new("github"), Name: new("octocat"), FullName: new("github/octocat"), Branch: new("main"), Active: new(true)},
+			{Org: new("github"), Name: new("broken"), FullName: new("github/broken"), Branch: new("main"), Active: new(true)},
+			{Org: new("github"), Name: new("inactive"), FullName: new("github/inactive"), Branch: new("main"), Active: new(false)},
+			{Org: new("other"), Name: new("octocat"), FullName: new("other/octocat"), Branch: new("main"), Active: new(true)},
+		})
+	})
+
+	push, _ := api.NewEventsFromSlice([]string{constants.EventPush, constants.EventTag, constants.EventPull})
+
+	secrets := map[string][]api.Secret{
+		"/api/v1/secrets/native/org/github/*": {
+			{Name: new("docker_password"), AllowEvents: push, AllowCommand: new(true)},
+			{Name: new("unused"), AllowEvents: push},
+		},
+		"/api/v1/secrets/native/repo/github/octocat": {
+			{Name: new("deploy_key"), AllowEvents: push, AllowCommand: new(true)},
+		},
+		"/api/v1/secrets/native/shared/github/team": {
+			{Name: new("shared_unused")},
+		},
+	}
+
+	mux.HandleFunc("GET /api/v1/secrets/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = w.Write([]byte(`[]`))
+
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(secrets[r.URL.Path])
+	})
+
+	mux.HandleFunc("POST /api/v1/pipelines/github/octocat/main/compile", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml")
+		_, _ = w.Write([]byte(testAuditPipeline))
+	})
+
+	mux.HandleFunc("POST /api/v1/pipelines/github/broken/main/compile", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid pipeline"}`))
+	})
+}
+
+func TestSecret_Audit(t *testing.T) {
+	s := fakeServer(t, func(mux *http.ServeMux) { testAuditRoutes(t, mux) })
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	// run test
+	got, err := c.Secret.Audit(t.Context(), &SecretAuditOptions{Org: "github", Teams: []string{"team"}})
+	if err != nil {
+		t.Fatalf("Audit returned err: %v", err)
+	}
+
+	if len(got.Secrets) != 4 {
+		t.Errorf("Audit returned %d secrets, want 4", len(got.Secrets))
+	}
+
+	unused := []string{}
+	for _, u := range got.Unused {
+		unused = append(unused, u.Target.Secret)
+	}
+
+	if !reflect.DeepEqual(unused, []string{"unused", "shared_unused"}) {
+		t.Errorf("Audit unused is %v, want %v", unused, []string{"unused", "shared_unused"})
+	}
+
+	if len(got.Dangling) != 1 || got.Dangling[0].Name != "missing" || got.Dangling[0].Step != "build" {
+		t.Errorf("Audit dangling is %v, want reference to missing from build", got.Dangling)
+	}
+
+	unverified := []string{}
+	for _, ref := range got.Unverified {
+		unverified = append(unverified, ref.Name)
+	}
+
+	if !reflect.DeepEqual(unverified, []string{"team_key", "other_org", "other_repo"}) {
+		t.Errorf("Audit unverified is %v, want %v", unverified, []string{"team_key", "other_org", "other_repo"})
+	}
+
+	if len(got.Errors) != 1 || got.Errors[0].Repo != "github/broken" {
+		t.Errorf("Audit errors is %v, want error for github/broken", got.Errors)
+	}
+
+	if len(got.Overbroad) != 2 {
+		t.Fatalf("Audit returned %d overbroad secrets, want 2", len(got.Overbroad))
+	}
+
+	// docker_password is used by push and tag steps, one of which runs commands
+	docker := got.Overbroad[0]
+	if docker.Target.Secret != "docker_password" || len(docker.References) != 2 {
+		t.Errorf("Audit overbroad is %v, want docker_password with 2 references", docker.Target)
+	}
+
+	wantEvents := []string{
+		constants.EventPull + ":" + constants.ActionOpened,
+		constants.EventPull + ":" + constants.ActionSynchronize,
+		constants.EventPull + ":" + constants.ActionReopened,
+	}
+
+	if !reflect.DeepEqual(docker.ExcessEvents, wantEvents) || docker.ExcessCommand {
+		t.Errorf("Audit docker_password excess is %v %v, want %v false", docker.ExcessEvents, docker.ExcessCommand, wantEvents)
+	}
+
+	// deploy_key is used by a step without an event ruleset or commands
+	deploy := got.Overbroad[1]
+	if deploy.Target.Secret != "deploy_key" || len(deploy.ExcessEvents) != 0 || !deploy.ExcessCommand {
+		t.Errorf("Audit deploy_key excess is %v %v, want none true", deploy.ExcessEvents, deploy.ExcessCommand)
+	}
+
+	if deploy.References[0].Step != "deploy" || deploy.References[0].Repo != "github/octocat" {
+		t.Errorf("Audit deploy_key reference is %v, want deploy step in github/octocat", deploy.References[0])
+	}
+}
+
+func TestSecret_Audit_NoOrg(t *testing.T) {
+	c, _ := NewClient("http://localhost:8080", "", nil)
+
+	// run test
+	_, err := c.Secret.Audit(t.Context(), &SecretAuditOptions{})
+	if err == nil {
+		t.Errorf("Audit should have returned err")
+	}
+}
+
+func TestSecret_secretTargetFromKey(t *testing.T) {
+	// run tests
+	tests := []struct {
+		name   string
+		secret string
+		key    string
+		sType  string
+		want   SecretTarget
+		ok     bool
+	}{
+		{
+			name:   "implicit repo",
+			secret: "foo",
+			want:   SecretTarget{Engine: "native", Type: "repo", Org: "github", Name: "octocat", Secret: "foo"},
+			ok:     true,
+		},
+		{
+			name:  "explicit repo",
+			key:   "other/repo/foo",
+			sType: "repo",
+			want:  SecretTarget{Engine: "native", Type: "repo", Org: "other", Name: "repo", Secret: "foo"},
+			ok:    true,
+		},
+		{
+			name:  "org",
+			key:   "github/foo",
+			sType: "org",
+			want:  SecretTarget{Engine: "native", Type: "org", Org: "github", Name: "*", Secret: "foo"},
+			ok:    true,
+		},
+		{
+			name:  "shared",
+			key:   "github/team/foo",
+			sType: "shared",
+			want:  SecretTarget{Engine: "native", Type: "shared", Org: "github", Name: "team", Secret: "foo"},
+			ok:    true,
+		},
+		{
+			name:  "bad org key",
+			key:   "foo",
+			sType: "org",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &yaml.Secret{Name: tt.secret, Key: tt.key, Type: tt.sType}
+
+			got, ok := secretTargetFromKey(s, "github", "octocat", "native")
+			if ok != tt.ok {
+				t.Errorf("secretTargetFromKey ok is %v, want %v", ok, tt.ok)
+			}
+
+			if ok && got != tt.want {
+				t.Errorf("secretTargetFromKey is %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,318 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	api "github.com/go-vela/server/api/types"
+	"github.com/go-vela/server/constants"
+)
+
+// SecretTarget identifies a single secret managed by
+// the server methods of the Vela API.
+type SecretTarget struct {
+	// Secret engine storing the secret.
+	//
+	// Default: native
+	Engine string
+
+	// Type of the secret.
+	//
+	// Can be: repo, org or shared
+	Type string
+
+	// Org the secret belongs to.
+	Org string
+
+	// Repo for repo secrets, team for shared
+	// secrets or "*" for org secrets.
+	Name string
+
+	// Name of the secret.
+	Secret string
+}
+
+// String returns the path of the secret target.
+func (t SecretTarget) String() string {
+	return fmt.Sprintf("%s/%s/%s/%s/%s", t.engine(), t.Type, t.Org, t.Name, t.Secret)
+}
+
+// engine returns the secret engine for the target.
+func (t SecretTarget) engine() string {
+	if len(t.Engine) == 0 {
+		return constants.DriverNative
+	}
+
+	return t.Engine
+}
+
+// SecretVerifyBuild identifies a build that is restarted
+// to verify a rotated secret is working as intended.
+type SecretVerifyBuild struct {
+	Org   string
+	Repo  string
+	Build int64
+}
+
+// SecretRotateOptions specifies the parameters to the
+// Secret.Rotate method.
+type SecretRotateOptions struct {
+	// Secrets to update with the new value.
+	Targets []SecretTarget
+
+	// Source for the new secret value.
+	Value SecretValueSource
+
+	// Source for the previous secret value used to roll
+	// back the targets when the rotation fails. Secret
+	// values can not be read back from the server so no
+	// rollback is attempted when this is not provided.
+	Previous SecretValueSource
+
+	// Builds restarted to verify the new value.
+	Verify []SecretVerifyBuild
+
+	// Interval between checks on verification builds.
+	//
+	// Default: 5s
+	PollInterval time.Duration
+
+	// Maximum time to wait for verification builds.
+	//
+	// Default: 30m
+	VerifyTimeout time.Duration
+
+	// Maximum time to spend rolling back the targets. The
+	// rollback runs even when the context for the rotation
+	// is canceled or its deadline has passed.
+	//
+	// Default: 1m
+	RollbackTimeout time.Duration
+}
+
+// SecretRotateResult represents the outcome of
+// rotating the secret for a single target.
+type SecretRotateResult struct {
+	Target SecretTarget
+
+	// Metadata of the secret captured before the update.
+	Previous *api.Secret
+
+	// Secret returned by the server after the update.
+	Updated *api.Secret
+
+	// Whether the target was restored to the previous value.
+	RolledBack bool
+
+	// Error encountered while updating or rolling back the target.
+	Err error
+}
+
+// SecretRotation represents the outcome of the
+// Secret.Rotate method.
+type SecretRotation struct {
+	Results []*SecretRotateResult
+
+	// Verification builds created by restarting the
+	// requested builds, with their final state.
+	Builds []*api.Build
+
+	// Whether the targets were rolled back.
+	RolledBack bool
+}
+
+// Rotate updates the secret across all of the provided targets and
+// optionally restarts builds to verify the new value. When an update
+// or verification fails, updated targets are rolled back to the
+// previous value provided by the caller.
+func (svc *SecretService) Rotate(ctx context.Context, opt *SecretRotateOptions) (*SecretRotation, error) {
+	if opt == nil || len(opt.Targets) == 0 {
+		return nil, fmt.Errorf("no secret targets provided")
+	}
+
+	if opt.Value == nil {
+		return nil, fmt.Errorf("no secret value source provided")
+	}
+
+	// resolve the values once so sources like stdin
+	// may be used across every target
+	value, err := opt.Value.Resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer clear(value)
+
+	var previous []byte
+
+	if opt.Previous != nil {
+		previous, err = opt.Previous.Resolve(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		defer clear(previous)
+	}
+
+	r := new(SecretRotation)
+
+	// capture the metadata for every target before
+	// making any changes so a missing secret fails fast
+	for _, t := range opt.Targets {
+		s, _, err := svc.Get(ctx, t.engine(), t.Type, t.Org, t.Name, t.Secret)
+		if err != nil {
+			return r, fmt.Errorf("unable to get secret %s: %w", t, err)
+		}
+
+		r.Results = append(r.Results, &SecretRotateResult{Target: t, Previous: s})
+	}
+
+	for _, res := range r.Results {
+		s, err := svc.updateValue(ctx, res.Target, value)
+		if err != nil {
+			res.Err = err
+
+			err = fmt.Errorf("unable to update secret %s: %w", res.Target, err)
+
+			return r, svc.rollback(ctx, r, previous, opt.RollbackTimeout, err)
+		}
+
+		res.Updated = s
+	}
+
+	err = svc.verify(ctx, r, opt)
+	if err != nil {
+		return r, svc.rollback(ctx, r, previous, opt.RollbackTimeout, err)
+	}
+
+	return r, nil
+}
+
+// updateValue sets the value for the secret target.
+func (svc *SecretService) updateValue(ctx context.Context, t SecretTarget, value []byte) (*api.Secret, error) {
+	// only the value is sent so other
+	// fields of the secret remain unchanged
+	s := &api.Secret{Name: new(t.Secret)}
+
+	// copy the value since it is zeroed after the request
+	src := SecretValueSourceFunc(func(context.Context) ([]byte, error) {
+		return slices.Clone(value), nil
+	})
+
+	v, _, err := svc.UpdateWithSource(ctx, t.engine(), t.Type, t.Org, t.Name, s, src)
+
+	return v, err
+}
+
+// rollback restores every updated target to the previous value
+// and returns the cause joined with any rollback errors. The
+// rollback is not canceled with the context, since the rotation
+// commonly fails because the context was canceled or timed out.
+func (svc *SecretService) rollback(ctx context.Context, r *SecretRotation, previous []byte, timeout time.Duration, cause error) error {
+	if previous == nil {
+		return fmt.Errorf("%w (no previous value provided, skipping rollback)", cause)
+	}
+
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	errs := []error{cause}
+
+	for _, res := range r.Results {
+		// skip targets that were never updated
+		if res.Updated == nil {
+			continue
+		}
+
+		_, err := svc.updateValue(ctx, res.Target, previous)
+		if err != nil {
+			res.Err = errors.Join(res.Err, err)
+
+			errs = append(errs, fmt.Errorf("unable to roll back secret %s: %w", res.Target, err))
+
+			continue
+		}
+
+		res.RolledBack = true
+	}
+
+	r.RolledBack = true
+
+	return errors.Join(errs...)
+}
+
+// verify restarts the verification builds and waits for them
+// to complete, returning an error if any were unsuccessful.
+func (svc *SecretService) verify(ctx context.Context, r *SecretRotation, opt *SecretRotateOptions) error {
+	if len(opt.Verify) == 0 {
+		return nil
+	}
+
+	timeout := opt.VerifyTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Minute
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for _, v := range opt.Verify {
+		b, _, err := svc.client.Build.Restart(ctx, v.Org, v.Repo, v.Build)
+		if err != nil {
+			return fmt.Errorf("unable to restart build %s/%s/%d: %w", v.Org, v.Repo, v.Build, err)
+		}
+
+		b, err = svc.client.Build.waitForBuild(ctx, v.Org, v.Repo, b.GetNumber(), opt.PollInterval)
+
+		r.Builds = append(r.Builds, b)
+
+		if err != nil {
+			return err
+		}
+
+		if b.GetStatus() != constants.StatusSuccess {
+			return fmt.Errorf("verification build %s/%s/%d finished with status %s", v.Org, v.Repo, b.GetNumber(), b.GetStatus())
+		}
+	}
+
+	return nil
+}
+
+// waitForBuild polls the provided build until it
+// reaches a final status or the context is done.
+func (svc *BuildService) waitForBuild(ctx context.Context, org, repo string, build int64, interval time.Duration) (*api.Build, error) {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		b, _, err := svc.Get(ctx, org, repo, build)
+		if err != nil {
+			return b, fmt.Errorf("unable to get build %s/%s/%d: %w", org, repo, build, err)
+		}
+
+		switch b.GetStatus() {
+		case constants.StatusPending, constants.StatusPendingApproval, constants.StatusRunning:
+		default:
+			return b, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return b, fmt.Errorf("timed out waiting for build %s/%s/%d: %w", org, repo, build, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,245 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	api "github.com/go-vela/server/api/types"
+	"github.com/go-vela/server/constants"
+)
+
+// rotateServer is a minimal stand-in for the Vela API
+// capturing secret updates made during a rotation.
+type rotateServer struct {
+	sync.Mutex
+
+	values      map[string]string
+	buildStatus string
+	failUpdate  string
+}
+
+func (rs *rotateServer) routes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/v1/secrets/native/{type}/{org}/{name}/{secret}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("secret") == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"not found"}`))
+
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(&api.Secret{Name: new(r.PathValue("secret")), Org: new(r.PathValue("org"))})
+	})
+
+	mux.HandleFunc("PUT /api/v1/secrets/native/{type}/{org}/{name}/{secret}", func(w http.ResponseWriter, r *http.Request) {
+		rs.Lock()
+		defer rs.Unlock()
+
+		path := strings.TrimPrefix(r.URL.Path, "/api/v1/secrets/native/")
+
+		s := new(api.Secret)
+		_ = json.NewDecoder(r.Body).Decode(s)
+
+		if path == rs.failUpdate && s.GetValue() == "new" {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"error":"boom"}`))
+
+			return
+		}
+
+		rs.values[path] = s.GetValue()
+
+		_ = json.NewEncoder(w).Encode(&api.Secret{Name: s.Name})
+	})
+
+	mux.HandleFunc("POST /api/v1/repos/{org}/{repo}/builds/{build}", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(&api.Build{Number: new(int64(2)), Status: new(constants.StatusPending)})
+	})
+
+	mux.HandleFunc("GET /api/v1/repos/{org}/{repo}/builds/{build}", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(&api.Build{Number: new(int64(2)), Status: new(rs.buildStatus)})
+	})
+}
+
+func testRotateOptions() *SecretRotateOptions {
+	return &SecretRotateOptions{
+		Targets: []SecretTarget{
+			{Type: "repo", Org: "github", Name: "octocat", Secret: "foo"},
+			{Type: "org", Org: "github", Name: "*", Secret: "foo"},
+			{Type: "shared", Org: "github", Name: "team", Secret: "foo"},
+		},
+		Value:        &StdinSecretSource{Reader: strings.NewReader("new")},
+		Previous:     &StdinSecretSource{Reader: strings.NewReader("old")},
+		Verify:       []SecretVerifyBuild{{Org: "github", Repo: "octocat", Build: 1}},
+		PollInterval: time.Millisecond,
+	}
+}
+
+func TestSecret_Rotate(t *testing.T) {
+	rs := &rotateServer{values: map[string]string{}, buildStatus: constants.StatusSuccess}
+
+	s := fakeServer(t, rs.routes)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	// run test
+	got, err := c.Secret.Rotate(t.Context(), testRotateOptions())
+	if err != nil {
+		t.Errorf("Rotate returned err: %v", err)
+	}
+
+	if got.RolledBack {
+		t.Errorf("Rotate should not have rolled back")
+	}
+
+	if len(got.Results) != 3 || len(got.Builds) != 1 {
+		t.Errorf("Rotate returned %d results and %d builds, want 3 and 1", len(got.Results), len(got.Builds))
+	}
+
+	for _, res := range got.Results {
+		if res.Previous.GetName() != "foo" {
+			t.Errorf("Rotate previous metadata is %v, want name foo", res.Previous)
+		}
+	}
+
+	for path, v := range rs.values {
+		if v != "new" {
+			t.Errorf("Rotate set %s to %q, want %q", path, v, "new")
+		}
+	}
+}
+
+func TestSecret_Rotate_VerifyFailure(t *testing.T) {
+	rs := &rotateServer{values: map[string]string{}, buildStatus: constants.StatusFailure}
+
+	s := fakeServer(t, rs.routes)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	// run test
+	got, err := c.Secret.Rotate(t.Context(), testRotateOptions())
+	if err == nil {
+		t.Errorf("Rotate should have returned err")
+	}
+
+	if !got.RolledBack {
+		t.Errorf("Rotate should have rolled back")
+	}
+
+	if len(rs.values) != 3 {
+		t.Errorf("Rotate updated %d targets, want 3", len(rs.values))
+	}
+
+	for path, v := range rs.values {
+		if v != "old" {
+			t.Errorf("Rotate set %s to %q, want %q", path, v, "old")
+		}
+	}
+}
+
+func TestSecret_Rotate_Canceled(t *testing.T) {
+	rs := &rotateServer{values: map[string]string{}, buildStatus: constants.StatusRunning}
+
+	s := fakeServer(t, rs.routes)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	// the verification build never finishes before the deadline
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+
+	// run test
+	got, err := c.Secret.Rotate(ctx, testRotateOptions())
+	if err == nil {
+		t.Errorf("Rotate should have returned err")
+	}
+
+	if !got.RolledBack {
+		t.Errorf("Rotate should have rolled back")
+	}
+
+	for path, v := range rs.values {
+		if v != "old" {
+			t.Errorf("Rotate set %s to %q, want %q", path, v, "old")
+		}
+	}
+}
+
+func TestSecret_Rotate_UpdateFailure(t *testing.T) {
+	rs := &rotateServer{values: map[string]string{}, buildStatus: constants.StatusSuccess, failUpdate: "org/github/*/foo"}
+
+	s := fakeServer(t, rs.routes)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	// run test
+	got, err := c.Secret.Rotate(t.Context(), testRotateOptions())
+	if err == nil {
+		t.Errorf("Rotate should have returned err")
+	}
+
+	if !got.Results[0].RolledBack || got.Results[1].RolledBack || got.Results[2].RolledBack {
+		t.Errorf("Rotate should have only rolled back the first target")
+	}
+
+	if rs.values["repo/github/octocat/foo"] != "old" {
+		t.Errorf("Rotate did not roll back the first target")
+	}
+
+	if _, ok := rs.values["shared/github/team/foo"]; ok {
+		t.Errorf("Rotate should not have updated the last target")
+	}
+}
+
+func TestSecret_Rotate_NoPrevious(t *testing.T) {
+	rs := &rotateServer{values: map[string]string{}, buildStatus: constants.StatusFailure}
+
+	s := fakeServer(t, rs.routes)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	opt := testRotateOptions()
+	opt.Previous = nil
+
+	// run test
+	got, err := c.Secret.Rotate(t.Context(), opt)
+	if err == nil || !strings.Contains(err.Error(), "skipping rollback") {
+		t.Errorf("Rotate should have returned skipped rollback err, got %v", err)
+	}
+
+	if got.RolledBack {
+		t.Errorf("Rotate should not have rolled back")
+	}
+}
+
+func TestSecret_Rotate_MissingSecret(t *testing.T) {
+	rs := &rotateServer{values: map[string]string{}, buildStatus: constants.StatusSuccess}
+
+	s := fakeServer(t, rs.routes)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	opt := testRotateOptions()
+	opt.Targets = append(opt.Targets, SecretTarget{Type: "repo", Org: "github", Name: "octocat", Secret: "missing"})
+
+	// run test
+	_, err := c.Secret.Rotate(t.Context(), opt)
+	if err == nil {
+		t.Errorf("Rotate should have returned err")
+	}
+
+	if len(rs.values) != 0 {
+		t.Errorf("Rotate should not have updated any targets")
+	}
+
+	_, err = c.Secret.Rotate(t.Context(), nil)
+	if err == nil {
+		t.Errorf("Rotate should have returned err")
+	}
+}
@@ -0,0 +1,291 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"unicode/utf8"
+
+	api "github.com/go-vela/server/api/types"
+)
+
+// SecretValueSource represents a provider capable of
+// resolving the value for a secret on demand.
+type SecretValueSource interface {
+	// Resolve returns the raw value for the secret.
+	//
+	// The returned slice is owned by the caller and
+	// will be zeroed once the value has been sent.
+	Resolve(ctx context.Context) ([]byte, error)
+}
+
+// SecretValueSourceFunc is an adapter to allow the use of
+// an ordinary function as a SecretValueSource.
+type SecretValueSourceFunc func(ctx context.Context) ([]byte, error)
+
+// Resolve calls f(ctx).
+func (f SecretValueSourceFunc) Resolve(ctx context.Context) ([]byte, error) {
+	return f(ctx)
+}
+
+// EnvSecretSource resolves a secret value from
+// the provided environment variable.
+type EnvSecretSource struct {
+	// Name of the environment variable to read.
+	Key string
+}
+
+// Resolve returns the value of the environment variable.
+func (s *EnvSecretSource) Resolve(_ context.Context) ([]byte, error) {
+	v, ok := os.LookupEnv(s.Key)
+	if !ok {
+		return nil, fmt.Errorf("environment variable %s is not set", s.Key)
+	}
+
+	return []byte(v), nil
+}
+
+// FileSecretSource resolves a secret value from the
+// contents of the provided file. The contents are
+// returned as-is, including any trailing newline.
+type FileSecretSource struct {
+	// Path of the file to read.
+	Path string
+}
+
+// Resolve returns the contents of the file.
+func (s *FileSecretSource) Resolve(_ context.Context) ([]byte, error) {
+	b, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read secret file %s: %w", s.Path, err)
+	}
+
+	return b, nil
+}
+
+// StdinSecretSource resolves a secret value by reading
+// until EOF from the provided reader. A single trailing
+// newline is removed from the value.
+type StdinSecretSource struct {
+	// Reader to consume the value from.
+	//
+	// Default: os.Stdin
+	Reader io.Reader
+}
+
+// Resolve returns the contents read from the reader.
+func (s *StdinSecretSource) Resolve(_ context.Context) ([]byte, error) {
+	r := s.Reader
+	if r == nil {
+		r = os.Stdin
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read secret from stdin: %w", err)
+	}
+
+	return trimNewline(b), nil
+}
+
+// CommandSecretSource resolves a secret value from the
+// standard output of an external command, such as a
+// password manager CLI. A single trailing newline is
+// removed from the value.
+type CommandSecretSource struct {
+	// Name of the command to execute.
+	Name string
+
+	// Arguments provided to the command.
+	Args []string
+
+	// Additional environment variables provided to the
+	// command in the form KEY=VALUE. The environment of
+	// the current process is always inherited.
+	Env []string
+}
+
+// Resolve executes the command and returns its output.
+func (s *CommandSecretSource) Resolve(ctx context.Context) ([]byte, error) {
+	if len(s.Name) == 0 {
+		return nil, fmt.Errorf("no command provided for secret source")
+	}
+
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+
+	//nolint:gosec // executing a caller provided command is the point
+	cmd := exec.CommandContext(ctx, s.Name, s.Args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if len(s.Env) > 0 {
+		cmd.Env = append(os.Environ(), s.Env...)
+	}
+
+	err := cmd.Run()
+	if err != nil {
+		clear(stdout.Bytes())
+
+		return nil, fmt.Errorf("unable to run secret command %s: %w: %s", s.Name, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return trimNewline(stdout.Bytes()), nil
+}
+
+// AddWithSource constructs a secret with the provided details,
+// resolving the secret value from the provided source. The
+// resolved value is zeroed from memory after the request.
+func (svc *SecretService) AddWithSource(ctx context.Context, engine, sType, org, name string, s *api.Secret, src SecretValueSource) (*api.Secret, *Response, error) {
+	// set the API endpoint path we send the request to
+	u := fmt.Sprintf("/api/v1/secrets/%s/%s/%s/%s", engine, sType, org, name)
+
+	return svc.callWithSource(ctx, "POST", u, s, src)
+}
+
+// UpdateWithSource modifies a secret with the provided details,
+// resolving the secret value from the provided source. The
+// resolved value is zeroed from memory after the request.
+func (svc *SecretService) UpdateWithSource(ctx context.Context, engine, sType, org, name string, s *api.Secret, src SecretValueSource) (*api.Secret, *Response, error) {
+	// set the API endpoint path we send the request to
+	u := fmt.Sprintf("/api/v1/secrets/%s/%s/%s/%s/%s", engine, sType, org, name, s.GetName())
+
+	return svc.callWithSource(ctx, "PUT", u, s, src)
+}
+
+// callWithSource resolves the secret value from the source,
+// sends the request and zeroes every buffer holding the value.
+func (svc *SecretService) callWithSource(ctx context.Context, method, u string, s *api.Secret, src SecretValueSource) (*api.Secret, *Response, error) {
+	if s == nil {
+		return nil, nil, fmt.Errorf("no secret provided")
+	}
+
+	if src == nil {
+		return nil, nil, fmt.Errorf("no secret value source provided")
+	}
+
+	value, err := src.Resolve(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer clear(value)
+
+	body, err := encodeSecretWithValue(s, value)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer clear(body)
+
+	// API Secret type we want to return
+	v := new(api.Secret)
+
+	// create the request with the encoded body
+	// so the encoder never copies the value
+	req, err := svc.client.NewRequest(ctx, method, u, io.NopCloser(bytes.NewReader(body)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// allow the body to be sent again when the
+	// request is retried with renewed credentials
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+
+	// send request using client
+	resp, err := svc.client.Do(req, v)
+
+	return v, resp, err
+}
+
+// encodeSecretWithValue JSON encodes the secret with the
+// provided value injected, without converting the value
+// to an immutable string along the way.
+func encodeSecretWithValue(s *api.Secret, value []byte) ([]byte, error) {
+	// never encode a value already set on the secret
+	cp := *s
+	cp.Value = nil
+
+	b, err := json.Marshal(&cp)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, len(b)+len(value)*2+16))
+
+	buf.WriteString(`{"value":`)
+	writeJSONString(buf, value)
+
+	// append the remaining fields of the encoded secret
+	if rest := b[1:]; len(rest) > 1 {
+		buf.WriteByte(',')
+		buf.Write(rest)
+	} else {
+		buf.WriteByte('}')
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeJSONString writes b to buf as a quoted JSON string.
+// Invalid UTF-8 is replaced with the Unicode replacement
+// character, the same as encoding/json.
+func writeJSONString(buf *bytes.Buffer, b []byte) {
+	const hex = "0123456789abcdef"
+
+	buf.WriteByte('"')
+
+	for i := 0; i < len(b); {
+		c := b[i]
+
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRune(b[i:])
+			if r == utf8.RuneError && size == 1 {
+				buf.WriteString(`\ufffd`)
+			} else {
+				buf.Write(b[i : i+size])
+			}
+
+			i += size
+
+			continue
+		}
+
+		switch {
+		case c == '"' || c == '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case c == '\n':
+			buf.WriteString(`\n`)
+		case c == '\r':
+			buf.WriteString(`\r`)
+		case c == '\t':
+			buf.WriteString(`\t`)
+		case c < 0x20:
+			buf.WriteString(`\u00`)
+			buf.WriteByte(hex[c>>4])
+			buf.WriteByte(hex[c&0xF])
+		default:
+			buf.WriteByte(c)
+		}
+
+		i++
+	}
+
+	buf.WriteByte('"')
+}
+
+// trimNewline removes a single trailing newline from b.
+func trimNewline(b []byte) []byte {
+	b = bytes.TrimSuffix(b, []byte("\n"))
+
+	return bytes.TrimSuffix(b, []byte("\r"))
+}
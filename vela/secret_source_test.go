@@ -0,0 +1,300 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	api "github.com/go-vela/server/api/types"
+	"github.com/go-vela/server/mock/server"
+)
+
+func TestSecret_EnvSecretSource(t *testing.T) {
+	t.Setenv("VELA_TEST_SECRET", "bar")
+
+	// run test
+	got, err := (&EnvSecretSource{Key: "VELA_TEST_SECRET"}).Resolve(t.Context())
+	if err != nil {
+		t.Errorf("Resolve returned err: %v", err)
+	}
+
+	if string(got) != "bar" {
+		t.Errorf("Resolve is %s, want %s", got, "bar")
+	}
+
+	_, err = (&EnvSecretSource{Key: "VELA_TEST_SECRET_MISSING"}).Resolve(t.Context())
+	if err == nil {
+		t.Errorf("Resolve should have returned err")
+	}
+}
+
+func TestSecret_FileSecretSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+
+	err := os.WriteFile(path, []byte("line1\nline2\n"), 0o600)
+	if err != nil {
+		t.Fatalf("unable to write file: %v", err)
+	}
+
+	// run test
+	got, err := (&FileSecretSource{Path: path}).Resolve(t.Context())
+	if err != nil {
+		t.Errorf("Resolve returned err: %v", err)
+	}
+
+	if string(got) != "line1\nline2\n" {
+		t.Errorf("Resolve is %q, want %q", got, "line1\nline2\n")
+	}
+
+	_, err = (&FileSecretSource{Path: filepath.Join(t.TempDir(), "missing")}).Resolve(t.Context())
+	if err == nil {
+		t.Errorf("Resolve should have returned err")
+	}
+}
+
+func TestSecret_StdinSecretSource(t *testing.T) {
+	// run test
+	got, err := (&StdinSecretSource{Reader: strings.NewReader("bar\n")}).Resolve(t.Context())
+	if err != nil {
+		t.Errorf("Resolve returned err: %v", err)
+	}
+
+	if string(got) != "bar" {
+		t.Errorf("Resolve is %q, want %q", got, "bar")
+	}
+}
+
+func TestSecret_CommandSecretSource(t *testing.T) {
+	// run test
+	got, err := (&CommandSecretSource{Name: "sh", Args: []string{"-c", "echo $VELA_TEST_SECRET"}, Env: []string{"VELA_TEST_SECRET=bar"}}).Resolve(t.Context())
+	if err != nil {
+		t.Errorf("Resolve returned err: %v", err)
+	}
+
+	if string(got) != "bar" {
+		t.Errorf("Resolve is %q, want %q", got, "bar")
+	}
+
+	_, err = (&CommandSecretSource{Name: "sh", Args: []string{"-c", "echo oops >&2; exit 1"}}).Resolve(t.Context())
+	if err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Errorf("Resolve should have returned err with stderr, got %v", err)
+	}
+
+	_, err = (&CommandSecretSource{}).Resolve(t.Context())
+	if err == nil {
+		t.Errorf("Resolve should have returned err")
+	}
+}
+
+func TestSecret_AddWithSource_201(t *testing.T) {
+	// setup context
+	gin.SetMode(gin.TestMode)
+
+	s := httptest.NewServer(server.FakeHandler())
+	c, _ := NewClient(s.URL, "", nil)
+
+	data := []byte(server.SecretResp)
+
+	var want api.Secret
+
+	_ = json.Unmarshal(data, &want)
+
+	req := api.Secret{
+		Org:         new("github"),
+		Repo:        new("octocat"),
+		Name:        new("foo"),
+		Images:      &[]string{"foo", "bar"},
+		AllowEvents: testEvents(),
+	}
+
+	value := []byte("bar")
+
+	src := SecretValueSourceFunc(func(_ context.Context) ([]byte, error) {
+		return value, nil
+	})
+
+	// run test
+	got, resp, err := c.Secret.AddWithSource(t.Context(), "native", "repo", "github", "octocat", &req, src)
+	if err != nil {
+		t.Errorf("AddWithSource returned err: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("AddWithSource returned %v, want %v", resp.StatusCode, http.StatusCreated)
+	}
+
+	if got.GetName() != want.GetName() {
+		t.Errorf("AddWithSource is %v, want %v", got, want)
+	}
+
+	if !bytes.Equal(value, make([]byte, len(value))) {
+		t.Errorf("AddWithSource did not zero the resolved value")
+	}
+
+	if req.Value != nil {
+		t.Errorf("AddWithSource should not set the value on the provided secret")
+	}
+}
+
+func TestSecret_UpdateWithSource_Body(t *testing.T) {
+	var got api.Secret
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/api/v1/secrets/native/org/github/*/foo" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		_ = json.NewDecoder(r.Body).Decode(&got)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name":"foo"}`))
+	}))
+	defer s.Close()
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	want := "multi\nline \"quoted\" \\ \x01 value"
+
+	req := api.Secret{
+		Name: new("foo"),
+	}
+
+	// run test
+	_, _, err := c.Secret.UpdateWithSource(t.Context(), "native", "org", "github", "*", &req, &StdinSecretSource{Reader: strings.NewReader(want)})
+	if err != nil {
+		t.Errorf("UpdateWithSource returned err: %v", err)
+	}
+
+	if got.GetValue() != want {
+		t.Errorf("UpdateWithSource sent value %q, want %q", got.GetValue(), want)
+	}
+
+	if got.GetName() != "foo" {
+		t.Errorf("UpdateWithSource sent name %q, want %q", got.GetName(), "foo")
+	}
+}
+
+func TestSecret_AddWithSource_Unauthorized(t *testing.T) {
+	var got api.Secret
+
+	s := fakeServer(t, func(mux *http.ServeMux) {
+		mux.HandleFunc("POST /authenticate/token", func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{"token":"access"}`))
+		})
+
+		mux.HandleFunc("POST /api/v1/secrets/native/repo/github/octocat", func(w http.ResponseWriter, r *http.Request) {
+			// reject the first request to force the retry
+			if got.Name == nil {
+				got.Name = new("")
+
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"error":"unauthorized"}`))
+
+				return
+			}
+
+			_ = json.NewDecoder(r.Body).Decode(&got)
+
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"name":"foo"}`))
+		})
+	})
+
+	c, _ := NewClient(s.URL, "", nil)
+	c.Authentication.SetPersonalAccessTokenAuth("pat")
+
+	// run test
+	_, resp, err := c.Secret.AddWithSource(t.Context(), "native", "repo", "github", "octocat", &api.Secret{Name: new("foo")}, &StdinSecretSource{Reader: strings.NewReader("bar")})
+	if err != nil {
+		t.Fatalf("AddWithSource returned err: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("AddWithSource returned %v, want %v", resp.StatusCode, http.StatusCreated)
+	}
+
+	if got.GetValue() != "bar" {
+		t.Errorf("AddWithSource sent value %q on retry, want %q", got.GetValue(), "bar")
+	}
+}
+
+func TestSecret_AddWithSource_ResolveError(t *testing.T) {
+	c, _ := NewClient("http://localhost:8080", "", nil)
+
+	src := SecretValueSourceFunc(func(_ context.Context) ([]byte, error) {
+		return nil, errors.New("boom")
+	})
+
+	// run test
+	_, _, err := c.Secret.AddWithSource(t.Context(), "native", "repo", "github", "octocat", &api.Secret{}, src)
+	if err == nil {
+		t.Errorf("AddWithSource should have returned err")
+	}
+
+	_, _, err = c.Secret.AddWithSource(t.Context(), "native", "repo", "github", "octocat", &api.Secret{}, nil)
+	if err == nil {
+		t.Errorf("AddWithSource should have returned err")
+	}
+}
+
+func TestSecret_encodeSecretWithValue_Empty(t *testing.T) {
+	// run test
+	got, err := encodeSecretWithValue(&api.Secret{}, []byte("bar"))
+	if err != nil {
+		t.Errorf("encodeSecretWithValue returned err: %v", err)
+	}
+
+	if string(got) != `{"value":"bar"}` {
+		t.Errorf("encodeSecretWithValue is %s, want %s", got, `{"value":"bar"}`)
+	}
+}
+
+func TestSecret_writeJSONString(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		name  string
+		value []byte
+	}{
+		{name: "ascii", value: []byte("bar")},
+		{name: "escaped", value: []byte("multi\nline \"quoted\" \\ \x01 value")},
+		{name: "multibyte", value: []byte("héllo, 世界")},
+		{name: "invalid", value: []byte("bad \xff\xfe value \xe4\xb8")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+
+			// run test
+			writeJSONString(buf, test.value)
+
+			if !json.Valid(buf.Bytes()) {
+				t.Fatalf("writeJSONString wrote invalid JSON %s", buf.Bytes())
+			}
+
+			var got, want string
+
+			_ = json.Unmarshal(buf.Bytes(), &got)
+
+			b, _ := json.Marshal(string(test.value))
+			_ = json.Unmarshal(b, &want)
+
+			if got != want {
+				t.Errorf("writeJSONString is %q, want %q", got, want)
+			}
+		})
+	}
+}
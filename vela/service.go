@@ -49,7 +49,15 @@ func (svc *SvcService) GetAll(ctx context.Context, org, repo string, build int64
 }
 
 // Add constructs a service with the provided details.
+//
+// Deprecated: use Client.ForBuild(org, repo, build).Services().Add, which takes the
+// build number as an int64.
 func (svc *SvcService) Add(ctx context.Context, org, repo string, build int, s *api.Service) (*api.Service, *Response, error) {
+	return svc.add(ctx, org, repo, int64(build), s)
+}
+
+// add constructs a service with the provided details.
+func (svc *SvcService) add(ctx context.Context, org, repo string, build int64, s *api.Service) (*api.Service, *Response, error) {
 	// set the API endpoint path we send the request to
 	u := fmt.Sprintf("/api/v1/repos/%s/%s/builds/%d/services", org, repo, build)
 
@@ -77,7 +85,16 @@ func (svc *SvcService) Update(ctx context.Context, org, repo string, build int64
 }
 
 // Remove deletes the provided service.
+//
+// Deprecated: use Client.ForBuild(org, repo, build).Services().Remove, which takes the
+// build number as an int64 and the service number as an int32.
 func (svc *SvcService) Remove(ctx context.Context, org, repo string, build, service int) (*string, *Response, error) {
+	//nolint:gosec // service numbers are int32 in the API
+	return svc.remove(ctx, org, repo, int64(build), int32(service))
+}
+
+// remove deletes the provided service.
+func (svc *SvcService) remove(ctx context.Context, org, repo string, build int64, service int32) (*string, *Response, error) {
 	// set the API endpoint path we send the request to
 	u := fmt.Sprintf("/api/v1/repos/%s/%s/builds/%d/services/%d", org, repo, build, service)
 
@@ -49,7 +49,15 @@ func (svc *StepService) GetAll(ctx context.Context, org, repo string, build int6
 }
 
 // Add constructs a step with the provided details.
+//
+// Deprecated: use Client.ForBuild(org, repo, build).Steps().Add, which takes the
+// build number as an int64.
 func (svc *StepService) Add(ctx context.Context, org, repo string, build int, s *api.Step) (*api.Step, *Response, error) {
+	return svc.add(ctx, org, repo, int64(build), s)
+}
+
+// add constructs a step with the provided details.
+func (svc *StepService) add(ctx context.Context, org, repo string, build int64, s *api.Step) (*api.Step, *Response, error) {
 	// set the API endpoint path we send the request to
 	u := fmt.Sprintf("/api/v1/repos/%s/%s/builds/%d/steps", org, repo, build)
 
@@ -77,7 +85,16 @@ func (svc *StepService) Update(ctx context.Context, org, repo string, build int6
 }
 
 // Remove deletes the provided step.
+//
+// Deprecated: use Client.ForBuild(org, repo, build).Steps().Remove, which takes the
+// build number as an int64 and the step number as an int32.
 func (svc *StepService) Remove(ctx context.Context, org, repo string, build, step int) (*string, *Response, error) {
+	//nolint:gosec // step numbers are int32 in the API
+	return svc.remove(ctx, org, repo, int64(build), int32(step))
+}
+
+// remove deletes the provided step.
+func (svc *StepService) remove(ctx context.Context, org, repo string, build int64, step int32) (*string, *Response, error) {
 	// set the API endpoint path we send the request to
 	u := fmt.Sprintf("/api/v1/repos/%s/%s/builds/%d/steps/%d", org, repo, build, step)
 
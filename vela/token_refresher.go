@@ -0,0 +1,237 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TokenRefresherOptions specifies the optional parameters
+// to the Authentication.StartRefresher method.
+type TokenRefresherOptions struct {
+	// Fraction of the lifetime of a token after which it is renewed.
+	//
+	// Default: 0.75
+	Fraction float64
+
+	// Time to wait before trying again after renewing fails,
+	// and between checks when there are no tokens to renew.
+	// Tokens are not renewed more often than the interval.
+	//
+	// Default: 30s
+	RetryInterval time.Duration
+
+	// Function called when renewing a token fails. When
+	// not provided, failures are logged at the debug level.
+	OnError func(error)
+}
+
+// tokenRefresher represents a running background refresher.
+type tokenRefresher struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// StartRefresher starts renewing the access token, for access and refresh
+// token authentication, and the SCM install token, for build token
+// authentication, in the background once the fraction of their lifetime
+// has passed. This keeps requests started close to the expiration of a
+// token from failing. The refresher runs until the context is canceled,
+// StopRefresher is called or the client is closed.
+func (svc *AuthenticationService) StartRefresher(ctx context.Context, opt *TokenRefresherOptions) error {
+	if opt == nil {
+		opt = new(TokenRefresherOptions)
+	}
+
+	o := *opt
+
+	if o.Fraction <= 0 || o.Fraction >= 1 {
+		o.Fraction = 0.75
+	}
+
+	if o.RetryInterval <= 0 {
+		o.RetryInterval = 30 * time.Second
+	}
+
+	svc.tokenMu.Lock()
+	defer svc.tokenMu.Unlock()
+
+	if svc.refresher != nil {
+		return fmt.Errorf("token refresher is already running")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	r := &tokenRefresher{cancel: cancel, done: make(chan struct{})}
+	svc.refresher = r
+
+	go svc.runRefresher(ctx, r, &o)
+
+	return nil
+}
+
+// StopRefresher stops the background refresher, if one is running,
+// canceling any renewal in progress and waiting for it to return.
+func (svc *AuthenticationService) StopRefresher() {
+	svc.tokenMu.Lock()
+	r := svc.refresher
+	svc.refresher = nil
+	svc.tokenMu.Unlock()
+
+	if r == nil {
+		return
+	}
+
+	r.cancel()
+
+	<-r.done
+}
+
+// runRefresher renews tokens as they become due until the context is canceled.
+func (svc *AuthenticationService) runRefresher(ctx context.Context, r *tokenRefresher, opt *TokenRefresherOptions) {
+	defer close(r.done)
+
+	var renewed bool
+
+	for {
+		wait := opt.RetryInterval
+
+		if next := svc.nextRenewal(opt.Fraction); !next.IsZero() {
+			wait = time.Until(next)
+
+			// keep a token that is due as soon as it is
+			// received from being renewed in a loop
+			if renewed {
+				wait = max(wait, opt.RetryInterval)
+			}
+		}
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return
+		case <-timer.C:
+		}
+
+		err := svc.renewDue(ctx, opt.Fraction)
+
+		renewed = true
+
+		switch {
+		case err == nil, ctx.Err() != nil:
+		case opt.OnError != nil:
+			opt.OnError(err)
+		default:
+			logrus.Debugf("unable to renew tokens: %v", err)
+		}
+	}
+}
+
+// nextRenewal returns the earliest time a token is due to be
+// renewed, which is zero when there are no tokens to renew.
+func (svc *AuthenticationService) nextRenewal(fraction float64) time.Time {
+	var next time.Time
+
+	for _, t := range []time.Time{svc.accessRenewal(fraction), svc.scmRenewal(fraction)} {
+		if !t.IsZero() && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+
+	return next
+}
+
+// renewDue renews the tokens that are due to be renewed.
+func (svc *AuthenticationService) renewDue(ctx context.Context, fraction float64) error {
+	var errs []error
+
+	now := time.Now()
+
+	if t := svc.accessRenewal(fraction); !t.IsZero() && !t.After(now) {
+		refresh, err := svc.getRefreshToken()
+		if err == nil {
+			_, err = svc.RefreshAccessToken(ctx, refresh)
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to renew access token: %w", err))
+		}
+	}
+
+	if t := svc.scmRenewal(fraction); !t.IsZero() && !t.After(now) {
+		svc.scmAuthMu.Lock()
+		err := svc.refreshBuildInstallToken(ctx)
+		svc.scmAuthMu.Unlock()
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to renew SCM install token: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// accessRenewal returns the time the access token is due to be renewed.
+func (svc *AuthenticationService) accessRenewal(fraction float64) time.Time {
+	if !svc.HasAccessAndRefreshAuth() {
+		return time.Time{}
+	}
+
+	svc.tokenMu.RLock()
+	access, received := svc.accessToken, svc.accessTokenAt
+	svc.tokenMu.RUnlock()
+
+	if access == nil {
+		return time.Time{}
+	}
+
+	c, err := ParseTokenClaims(*access)
+	if err != nil || c.ExpiresAt.IsZero() {
+		return time.Time{}
+	}
+
+	// without the issue time the lifetime is unknown,
+	// so renew shortly before the token expires
+	if c.IssuedAt.IsZero() {
+		return c.ExpiresAt.Add(-time.Minute)
+	}
+
+	// the claims use the clock of the server, so tokens received
+	// from the server are renewed based on the local time they
+	// were received and their lifetime
+	if !received.IsZero() {
+		return renewalTime(received, received.Add(c.ExpiresAt.Sub(c.IssuedAt)), fraction)
+	}
+
+	return renewalTime(c.IssuedAt, c.ExpiresAt, fraction)
+}
+
+// scmRenewal returns the time the SCM install token is due to be renewed.
+func (svc *AuthenticationService) scmRenewal(fraction float64) time.Time {
+	if !svc.HasBuildTokenAuth() {
+		return time.Time{}
+	}
+
+	svc.scmAuthMu.RLock()
+	defer svc.scmAuthMu.RUnlock()
+
+	// only install tokens have an expiration
+	if svc.scmTokenExp == nil {
+		return time.Time{}
+	}
+
+	return renewalTime(svc.scmTokenAt, time.Unix(*svc.scmTokenExp, 0), fraction)
+}
+
+// renewalTime returns the time the fraction of the lifetime has passed.
+func renewalTime(issued, expires time.Time, fraction float64) time.Time {
+	return issued.Add(time.Duration(float64(expires.Sub(issued)) * fraction))
+}
@@ -0,0 +1,233 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+
+	api "github.com/go-vela/server/api/types"
+)
+
+// waitFor waits for the condition to be met, failing the test after 5s.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for condition")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAuthentication_StartRefresher_Access(t *testing.T) {
+	var refreshes atomic.Int32
+
+	s := fakeServer(t, func(mux *http.ServeMux) {
+		mux.HandleFunc("GET /token-refresh", func(w http.ResponseWriter, _ *http.Request) {
+			refreshes.Add(1)
+
+			tkn := makeSampleToken(jwt.MapClaims{
+				"iat": float64(time.Now().Unix()),
+				"exp": float64(time.Now().Add(time.Hour).Unix()),
+			})
+
+			_ = json.NewEncoder(w).Encode(api.Token{Token: new(tkn)})
+		})
+	})
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	// access token halfway through its lifetime
+	access := makeSampleToken(jwt.MapClaims{
+		"iat": float64(time.Now().Add(-time.Minute).Unix()),
+		"exp": float64(time.Now().Add(time.Minute).Unix()),
+	})
+
+	c.Authentication.SetAccessAndRefreshAuth(access, "refresh")
+
+	// run test
+	err := c.Authentication.StartRefresher(t.Context(), &TokenRefresherOptions{Fraction: 0.5})
+	if err != nil {
+		t.Fatalf("StartRefresher returned err: %v", err)
+	}
+
+	err = c.Authentication.StartRefresher(t.Context(), nil)
+	if err == nil {
+		t.Errorf("StartRefresher should have returned err when already running")
+	}
+
+	waitFor(t, func() bool {
+		got, _ := c.Authentication.getAccessToken()
+
+		return got != access
+	})
+
+	err = c.Close()
+	if err != nil {
+		t.Errorf("Close returned err: %v", err)
+	}
+
+	// the new token is not due, so it is only renewed once
+	if refreshes.Load() != 1 {
+		t.Errorf("StartRefresher renewed %d times, want 1", refreshes.Load())
+	}
+}
+
+func TestAuthentication_StartRefresher_SCM(t *testing.T) {
+	s := fakeServer(t, func(mux *http.ServeMux) {
+		mux.HandleFunc("GET /api/v1/repos/github/octocat/builds/1/install_token", func(w http.ResponseWriter, _ *http.Request) {
+			_ = json.NewEncoder(w).Encode(api.Token{Token: new("new-scm"), Expiration: new(time.Now().Add(time.Hour).Unix())})
+		})
+	})
+
+	c, _ := NewClient(s.URL, "", nil)
+	c.Authentication.SetBuildTokenAuth("build", "scm", time.Now().Add(time.Second).Unix(), "github/octocat", 1)
+
+	// run test
+	err := c.Authentication.StartRefresher(t.Context(), &TokenRefresherOptions{Fraction: 0.5})
+	if err != nil {
+		t.Fatalf("StartRefresher returned err: %v", err)
+	}
+
+	defer c.Close()
+
+	waitFor(t, func() bool {
+		return c.Authentication.SCMToken() == "new-scm"
+	})
+}
+
+func TestAuthentication_StartRefresher_OnError(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":"refresh token expired"}`))
+	}))
+	defer s.Close()
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	// expired access token
+	access := makeSampleToken(jwt.MapClaims{
+		"iat": float64(time.Now().Add(-time.Hour).Unix()),
+		"exp": float64(time.Now().Add(-time.Minute).Unix()),
+	})
+
+	c.Authentication.SetAccessAndRefreshAuth(access, "refresh")
+
+	errs := make(chan error, 10)
+
+	// run test
+	err := c.Authentication.StartRefresher(t.Context(), &TokenRefresherOptions{
+		RetryInterval: time.Millisecond,
+		OnError: func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("StartRefresher returned err: %v", err)
+	}
+
+	defer c.Close()
+
+	// failures are retried after the retry interval
+	for range 2 {
+		select {
+		case <-errs:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("StartRefresher did not report the failure")
+		}
+	}
+}
+
+func TestAuthentication_StartRefresher_ServerClock(t *testing.T) {
+	var refreshes atomic.Int32
+
+	// the clock of the server is two hours behind
+	skew := -2 * time.Hour
+
+	s := fakeServer(t, func(mux *http.ServeMux) {
+		mux.HandleFunc("GET /token-refresh", func(w http.ResponseWriter, _ *http.Request) {
+			refreshes.Add(1)
+
+			tkn := makeSampleToken(jwt.MapClaims{
+				"iat": float64(time.Now().Add(skew).Unix()),
+				"exp": float64(time.Now().Add(skew + time.Hour).Unix()),
+			})
+
+			_ = json.NewEncoder(w).Encode(api.Token{Token: new(tkn)})
+		})
+	})
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	access := makeSampleToken(jwt.MapClaims{
+		"iat": float64(time.Now().Add(skew).Unix()),
+		"exp": float64(time.Now().Add(skew + time.Hour).Unix()),
+	})
+
+	refresh := makeSampleToken(jwt.MapClaims{
+		"iat": float64(time.Now().Add(skew).Unix()),
+		"exp": float64(time.Now().Add(skew + 8*time.Hour).Unix()),
+	})
+
+	c.Authentication.SetAccessAndRefreshAuth(access, refresh)
+
+	// run test
+	err := c.Authentication.StartRefresher(t.Context(), &TokenRefresherOptions{RetryInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("StartRefresher returned err: %v", err)
+	}
+
+	waitFor(t, func() bool {
+		return refreshes.Load() > 0
+	})
+
+	// the renewed token is due based on the time it was received
+	time.Sleep(100 * time.Millisecond)
+
+	err = c.Close()
+	if err != nil {
+		t.Errorf("Close returned err: %v", err)
+	}
+
+	if refreshes.Load() != 1 {
+		t.Errorf("StartRefresher renewed %d times, want 1", refreshes.Load())
+	}
+}
+
+func TestAuthentication_StartRefresher_SetAuth(t *testing.T) {
+	s := fakeServer(t, nil)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	// run test
+	err := c.Authentication.StartRefresher(t.Context(), &TokenRefresherOptions{RetryInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("StartRefresher returned err: %v", err)
+	}
+
+	defer c.Close()
+
+	// the auth and settings are changed while the refresher checks for tokens to renew
+	for range 100 {
+		c.Authentication.SetTokenAuth("token")
+		c.Authentication.SetPersonalAccessTokenAuth("token")
+		c.Authentication.SetTokenExpirySkew(time.Second)
+		c.Authentication.SetCredentialStore(nil, "local")
+
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,352 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+
+	api "github.com/go-vela/server/api/types"
+)
+
+// TokenVerifierOptions specifies the parameters to the
+// NewTokenVerifier function. An audience is required unless
+// the audience check is skipped, any other claims left empty
+// are not checked.
+type TokenVerifierOptions struct {
+	// Issuer the tokens must be issued by.
+	//
+	// Default: the issuer from the OpenID configuration for the server
+	Issuer string
+
+	// Audiences the tokens must all be issued for.
+	Audience []string
+
+	// Skip checking the audiences of the tokens, accepting
+	// tokens issued for any audience. Only intended for
+	// tokens that are not sent on to another service.
+	SkipAudienceCheck bool
+
+	// Repo as <org>/<repo> the tokens must be issued for.
+	Repo string
+
+	// Build number the tokens must be issued for.
+	Build int64
+
+	// Event the tokens must be issued for.
+	Event string
+
+	// Ref the tokens must be issued for.
+	Ref string
+
+	// Function called with the claims to check any other claims,
+	// such as custom properties. Returning an error rejects the token.
+	Claims func(*api.OpenIDClaims) error
+
+	// Time allowed for clock skew when checking the expiration.
+	Leeway time.Duration
+
+	// Time the OpenID configuration and keys are cached for.
+	//
+	// Default: 15m
+	CacheTTL time.Duration
+
+	// Minimum time between fetching the keys when a
+	// token is signed with a key that is not cached.
+	//
+	// Default: 30s
+	MinRefreshInterval time.Duration
+}
+
+// TokenVerifier verifies ID tokens issued by the server
+// against the OpenID configuration and keys for the server,
+// without sending the tokens to the server.
+type TokenVerifier struct {
+	client *Client
+	opt    TokenVerifierOptions
+
+	mu        sync.Mutex
+	issuer    string
+	issuerAt  time.Time
+	keys      map[string]*rsa.PublicKey
+	keysAt    time.Time
+	rotations uint64
+}
+
+// NewTokenVerifier returns a verifier of ID tokens issued by the server
+// for the client. Rotating the keys with the Admin.OIDC.RotateOIDCKeys
+// method of the client drops the keys cached by the verifier.
+func NewTokenVerifier(c *Client, opt *TokenVerifierOptions) (*TokenVerifier, error) {
+	if c == nil {
+		return nil, fmt.Errorf("no client provided")
+	}
+
+	if opt == nil {
+		opt = new(TokenVerifierOptions)
+	}
+
+	// accepting tokens issued for any audience must be explicit
+	if len(opt.Audience) == 0 && !opt.SkipAudienceCheck {
+		return nil, fmt.Errorf("no audience provided for verifying tokens")
+	}
+
+	v := &TokenVerifier{
+		client: c,
+		opt:    *opt,
+	}
+
+	if v.opt.CacheTTL <= 0 {
+		v.opt.CacheTTL = 15 * time.Minute
+	}
+
+	if v.opt.MinRefreshInterval <= 0 {
+		v.opt.MinRefreshInterval = 30 * time.Second
+	}
+
+	return v, nil
+}
+
+// Verify checks the signature of the token against the keys for the
+// server and that the claims match the options for the verifier.
+func (v *TokenVerifier) Verify(ctx context.Context, token string) (*api.OpenIDClaims, error) {
+	return v.verify(ctx, token, v.opt.Audience)
+}
+
+// Refresh drops the cached OpenID configuration and keys
+// and fetches the current ones from the server.
+func (v *TokenVerifier) Refresh(ctx context.Context) error {
+	v.mu.Lock()
+
+	v.issuer = ""
+
+	err := v.fetchKeys(ctx)
+
+	v.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = v.getIssuer(ctx)
+
+	return err
+}
+
+// verify checks the token for the audiences in place of
+// the audiences from the options for the verifier.
+func (v *TokenVerifier) verify(ctx context.Context, token string, audience []string) (*api.OpenIDClaims, error) {
+	issuer, err := v.getIssuer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := new(api.OpenIDClaims)
+
+	_, err = jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+
+		return v.getKey(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuer(issuer),
+		jwt.WithLeeway(v.opt.Leeway),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, aud := range audience {
+		if !slices.Contains(claims.Audience, aud) {
+			return nil, fmt.Errorf("token is missing audience %s", aud)
+		}
+	}
+
+	err = v.checkClaims(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// checkClaims checks the custom claims for Vela against the options.
+func (v *TokenVerifier) checkClaims(claims *api.OpenIDClaims) error {
+	if len(v.opt.Repo) > 0 && claims.Repo != v.opt.Repo {
+		return fmt.Errorf("token is for repo %s, not %s", claims.Repo, v.opt.Repo)
+	}
+
+	if v.opt.Build > 0 && claims.BuildNumber != strconv.FormatInt(v.opt.Build, 10) {
+		return fmt.Errorf("token is for build %s, not %d", claims.BuildNumber, v.opt.Build)
+	}
+
+	if len(v.opt.Event) > 0 && claims.Event != v.opt.Event {
+		return fmt.Errorf("token is for event %s, not %s", claims.Event, v.opt.Event)
+	}
+
+	if len(v.opt.Ref) > 0 && claims.Ref != v.opt.Ref {
+		return fmt.Errorf("token is for ref %s, not %s", claims.Ref, v.opt.Ref)
+	}
+
+	if v.opt.Claims != nil {
+		return v.opt.Claims(claims)
+	}
+
+	return nil
+}
+
+// getIssuer returns the issuer from the options, or from the
+// OpenID configuration for the server when none was provided.
+func (v *TokenVerifier) getIssuer(ctx context.Context) (string, error) {
+	if len(v.opt.Issuer) > 0 {
+		return v.opt.Issuer, nil
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if len(v.issuer) > 0 && time.Since(v.issuerAt) < v.opt.CacheTTL {
+		return v.issuer, nil
+	}
+
+	cfg, _, err := v.client.OIDC.GetOpenIDConfig(ctx)
+	if err != nil {
+		return "", fmt.Errorf("unable to get OpenID configuration: %w", err)
+	}
+
+	if len(cfg.Issuer) == 0 {
+		return "", fmt.Errorf("no issuer in OpenID configuration")
+	}
+
+	v.issuer = cfg.Issuer
+	v.issuerAt = time.Now()
+
+	return v.issuer, nil
+}
+
+// getKey returns the key for the kid, fetching the keys when the
+// cached keys expired, were rotated or do not include the kid.
+func (v *TokenVerifier) getKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.keys == nil || time.Since(v.keysAt) >= v.opt.CacheTTL || v.rotations != v.client.oidcRotations.Load() {
+		err := v.fetchKeys(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+
+	// the keys may have been rotated since they were fetched,
+	// but limit how often an unknown kid can trigger a fetch
+	if time.Since(v.keysAt) < v.opt.MinRefreshInterval {
+		return nil, fmt.Errorf("no key found for kid %q", kid)
+	}
+
+	err := v.fetchKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok := v.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("no key found for kid %q", kid)
+	}
+
+	return key, nil
+}
+
+// verifierJWK represents a key from the JWKS for the server
+// along with the intended use of the key, which the API JWK
+// type does not capture.
+type verifierJWK struct {
+	api.JWK
+
+	Use string `json:"use,omitempty"`
+}
+
+// fetchKeys replaces the cached keys with the current keys for the server.
+// Keys that can't be used to verify tokens, such as keys of another type
+// published during a rotation, are skipped.
+func (v *TokenVerifier) fetchKeys(ctx context.Context) error {
+	// capture the rotations before fetching so a rotation
+	// during the fetch leads to fetching the keys again
+	rotations := v.client.oidcRotations.Load()
+
+	// set the API endpoint path we send the request to
+	u := "/_services/token/.well-known/jwks"
+
+	// JWKS type we want to return
+	set := new(struct {
+		Keys []verifierJWK `json:"keys"`
+	})
+
+	// send request using client
+	_, err := v.client.Call(ctx, "GET", u, nil, set)
+	if err != nil {
+		return fmt.Errorf("unable to get JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+
+	for _, k := range set.Keys {
+		if len(k.Use) > 0 && k.Use != "sig" {
+			continue
+		}
+
+		key, err := rsaPublicKey(k.JWK)
+		if err != nil {
+			logrus.Debugf("skipping key %s from JWKS: %v", k.Kid, err)
+
+			continue
+		}
+
+		keys[k.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return fmt.Errorf("no usable keys found in JWKS")
+	}
+
+	v.keys = keys
+	v.keysAt = time.Now()
+	v.rotations = rotations
+
+	return nil
+}
+
+// rsaPublicKey returns the RSA public key for the JWK.
+func rsaPublicKey(k api.JWK) (*rsa.PublicKey, error) {
+	if k.Kty != "RSA" {
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
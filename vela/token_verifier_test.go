@@ -0,0 +1,252 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+
+	api "github.com/go-vela/server/api/types"
+)
+
+func testOpenIDClaims() api.OpenIDClaims {
+	return api.OpenIDClaims{
+		Repo:        "github/octocat",
+		BuildNumber: "1",
+		Event:       "push",
+		Ref:         "refs/heads/main",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{"vault"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+}
+
+func TestTokenVerifier_Verify(t *testing.T) {
+	is := newIDTokenServer(t)
+
+	s := fakeServer(t, func(mux *http.ServeMux) { is.routes(t, mux) })
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	v, err := NewTokenVerifier(c, &TokenVerifierOptions{
+		Audience: []string{"vault"},
+		Repo:     "github/octocat",
+		Build:    1,
+		Event:    "push",
+		Ref:      "refs/heads/main",
+	})
+	if err != nil {
+		t.Fatalf("NewTokenVerifier returned err: %v", err)
+	}
+
+	// run test
+	for range 2 {
+		got, err := v.Verify(t.Context(), is.sign(t, testOpenIDClaims()))
+		if err != nil {
+			t.Fatalf("Verify returned err: %v", err)
+		}
+
+		if got.Repo != "github/octocat" {
+			t.Errorf("Verify returned claims for repo %s", got.Repo)
+		}
+	}
+
+	// the configuration and keys are cached
+	if is.configs != 1 || is.jwks != 1 {
+		t.Errorf("Verify fetched the configuration %d times and keys %d times, want 1", is.configs, is.jwks)
+	}
+}
+
+func TestTokenVerifier_Verify_Invalid(t *testing.T) {
+	is := newIDTokenServer(t)
+
+	s := fakeServer(t, func(mux *http.ServeMux) { is.routes(t, mux) })
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	// setup tests
+	tests := []struct {
+		name   string
+		opt    *TokenVerifierOptions
+		claims func(*api.OpenIDClaims)
+	}{
+		{
+			name:   "expired",
+			opt:    &TokenVerifierOptions{},
+			claims: func(c *api.OpenIDClaims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute)) },
+		},
+		{
+			name:   "no expiration",
+			opt:    &TokenVerifierOptions{},
+			claims: func(c *api.OpenIDClaims) { c.ExpiresAt = nil },
+		},
+		{
+			name: "issuer",
+			opt:  &TokenVerifierOptions{Issuer: "https://example.com"},
+		},
+		{
+			name: "audience",
+			opt:  &TokenVerifierOptions{Audience: []string{"sts.amazonaws.com"}},
+		},
+		{
+			name: "build",
+			opt:  &TokenVerifierOptions{Build: 2},
+		},
+		{
+			name: "event",
+			opt:  &TokenVerifierOptions{Event: "pull_request"},
+		},
+		{
+			name: "ref",
+			opt:  &TokenVerifierOptions{Ref: "refs/heads/dev"},
+		},
+		{
+			name: "custom claims",
+			opt: &TokenVerifierOptions{Claims: func(*api.OpenIDClaims) error {
+				return fmt.Errorf("custom property missing")
+			}},
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			claims := testOpenIDClaims()
+
+			if test.claims != nil {
+				test.claims(&claims)
+			}
+
+			// check the audience of the token unless the test replaces it
+			if len(test.opt.Audience) == 0 {
+				test.opt.Audience = []string{"vault"}
+			}
+
+			v, err := NewTokenVerifier(c, test.opt)
+			if err != nil {
+				t.Fatalf("NewTokenVerifier returned err: %v", err)
+			}
+
+			_, err = v.Verify(t.Context(), is.sign(t, claims))
+			if err == nil {
+				t.Errorf("Verify should have returned err")
+			}
+		})
+	}
+}
+
+func TestTokenVerifier_Verify_Rotation(t *testing.T) {
+	is := newIDTokenServer(t)
+
+	s := fakeServer(t, func(mux *http.ServeMux) { is.routes(t, mux) })
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	v, _ := NewTokenVerifier(c, &TokenVerifierOptions{Audience: []string{"vault"}, MinRefreshInterval: time.Hour})
+
+	_, err := v.Verify(t.Context(), is.sign(t, testOpenIDClaims()))
+	if err != nil {
+		t.Fatalf("Verify returned err: %v", err)
+	}
+
+	// run test
+	_, _, err = c.Admin.OIDC.RotateOIDCKeys(t.Context())
+	if err != nil {
+		t.Fatalf("RotateOIDCKeys returned err: %v", err)
+	}
+
+	_, err = v.Verify(t.Context(), is.sign(t, testOpenIDClaims()))
+	if err != nil {
+		t.Errorf("Verify returned err after rotation: %v", err)
+	}
+
+	// keys rotated elsewhere are only fetched for an unknown
+	// kid once the minimum refresh interval has passed
+	is.rotate(t)
+
+	_, err = v.Verify(t.Context(), is.sign(t, testOpenIDClaims()))
+	if err == nil {
+		t.Errorf("Verify should have returned err within the refresh interval")
+	}
+
+	v.opt.MinRefreshInterval = time.Nanosecond
+
+	_, err = v.Verify(t.Context(), is.sign(t, testOpenIDClaims()))
+	if err != nil {
+		t.Errorf("Verify returned err for unknown kid: %v", err)
+	}
+
+	if is.jwks != 3 {
+		t.Errorf("Verify fetched keys %d times, want 3", is.jwks)
+	}
+}
+
+func TestTokenVerifier_Verify_SkipAudienceCheck(t *testing.T) {
+	is := newIDTokenServer(t)
+
+	s := fakeServer(t, func(mux *http.ServeMux) { is.routes(t, mux) })
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	// run test
+	_, err := NewTokenVerifier(c, nil)
+	if err == nil {
+		t.Errorf("NewTokenVerifier should have returned err without an audience")
+	}
+
+	v, err := NewTokenVerifier(c, &TokenVerifierOptions{SkipAudienceCheck: true})
+	if err != nil {
+		t.Fatalf("NewTokenVerifier returned err: %v", err)
+	}
+
+	_, err = v.Verify(t.Context(), is.sign(t, testOpenIDClaims()))
+	if err != nil {
+		t.Errorf("Verify returned err: %v", err)
+	}
+}
+
+func TestTokenVerifier_Verify_UnsupportedKeys(t *testing.T) {
+	is := newIDTokenServer(t)
+
+	s := fakeServer(t, func(mux *http.ServeMux) { is.routes(t, mux) })
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	v, _ := NewTokenVerifier(c, &TokenVerifierOptions{Audience: []string{"vault"}})
+
+	// keys of another type or use are published alongside the signing key
+	is.Lock()
+	is.extra = []map[string]string{
+		{"kty": "EC", "kid": "key_ec", "crv": "P-256", "x": "x", "y": "y"},
+		{"kty": "RSA", "kid": "key_enc", "use": "enc", "n": "n", "e": "AQAB"},
+	}
+	is.Unlock()
+
+	// run test
+	_, err := v.Verify(t.Context(), is.sign(t, testOpenIDClaims()))
+	if err != nil {
+		t.Errorf("Verify returned err: %v", err)
+	}
+
+	// no usable key is left without the signing key
+	s = fakeServer(t, func(mux *http.ServeMux) {
+		mux.HandleFunc("GET /_services/token/.well-known/jwks", func(w http.ResponseWriter, _ *http.Request) {
+			_ = json.NewEncoder(w).Encode(map[string]any{"keys": is.extra})
+		})
+	})
+
+	c, _ = NewClient(s.URL, "", nil)
+
+	v, _ = NewTokenVerifier(c, &TokenVerifierOptions{Audience: []string{"vault"}})
+
+	err = v.Refresh(t.Context())
+	if err == nil {
+		t.Errorf("Refresh should have returned err without a usable key")
+	}
+}
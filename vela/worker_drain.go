@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	api "github.com/go-vela/server/api/types"
+)
+
+// WorkerDrainOptions specifies the optional parameters
+// to the Worker.Drain method.
+type WorkerDrainOptions struct {
+	// Time between checks of the running builds for the worker.
+	//
+	// Default: 10s
+	PollInterval time.Duration
+
+	// Time to wait for the running builds to finish. When
+	// zero, Drain waits until the context is canceled.
+	Timeout time.Duration
+
+	// Cancel the builds still running once the timeout elapses.
+	Cancel bool
+}
+
+// WorkerDrain represents the outcome of draining a worker.
+type WorkerDrain struct {
+	// Worker as of the last check of its running builds.
+	Worker *api.Worker
+
+	// Whether the timeout elapsed before the running builds finished.
+	TimedOut bool
+
+	// Builds canceled once the timeout elapsed.
+	Canceled []*api.Build
+}
+
+// Cordon marks the worker inactive so it stops receiving new builds.
+func (svc *WorkerService) Cordon(ctx context.Context, hostname string) (*api.Worker, *Response, error) {
+	return svc.Update(ctx, hostname, &api.Worker{Hostname: new(hostname), Active: new(false)})
+}
+
+// Uncordon marks the worker active so it receives new builds again.
+func (svc *WorkerService) Uncordon(ctx context.Context, hostname string) (*api.Worker, *Response, error) {
+	return svc.Update(ctx, hostname, &api.Worker{Hostname: new(hostname), Active: new(true)})
+}
+
+// Drain cordons the worker and waits for its running builds to finish.
+// When the timeout elapses first, the remaining builds are canceled if
+// requested, otherwise an error is returned along with the drain.
+func (svc *WorkerService) Drain(ctx context.Context, hostname string, opt *WorkerDrainOptions) (*WorkerDrain, error) {
+	if opt == nil {
+		opt = new(WorkerDrainOptions)
+	}
+
+	interval := opt.PollInterval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	_, _, err := svc.Cordon(ctx, hostname)
+	if err != nil {
+		return nil, fmt.Errorf("unable to cordon worker %s: %w", hostname, err)
+	}
+
+	var timeout <-chan time.Time
+
+	if opt.Timeout > 0 {
+		timer := time.NewTimer(opt.Timeout)
+		defer timer.Stop()
+
+		timeout = timer.C
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	d := new(WorkerDrain)
+
+	for {
+		w, _, err := svc.Get(ctx, hostname)
+		if err != nil {
+			return d, fmt.Errorf("unable to get worker %s: %w", hostname, err)
+		}
+
+		d.Worker = w
+
+		if len(w.GetRunningBuilds()) == 0 {
+			return d, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return d, ctx.Err()
+		case <-timeout:
+			d.TimedOut = true
+
+			if !opt.Cancel {
+				return d, fmt.Errorf("timed out waiting for %d builds on worker %s", len(w.GetRunningBuilds()), hostname)
+			}
+
+			return d, svc.cancelBuilds(ctx, d, w.GetRunningBuilds())
+		case <-ticker.C:
+		}
+	}
+}
+
+// cancelBuilds cancels the builds, recording the canceled builds on the drain.
+func (svc *WorkerService) cancelBuilds(ctx context.Context, d *WorkerDrain, builds []*api.Build) error {
+	var errs []error
+
+	for _, b := range builds {
+		org, repo := b.GetRepo().GetOrg(), b.GetRepo().GetName()
+
+		// fall back to the full name when the worker
+		// only reports the full name for the repo
+		if len(org) == 0 || len(repo) == 0 {
+			org, repo, _ = strings.Cut(b.GetRepo().GetFullName(), "/")
+		}
+
+		c, _, err := svc.client.Build.Cancel(ctx, org, repo, b.GetNumber())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to cancel build %s/%s/%d: %w", org, repo, b.GetNumber(), err))
+
+			continue
+		}
+
+		d.Canceled = append(d.Canceled, c)
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,177 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	api "github.com/go-vela/server/api/types"
+	"github.com/go-vela/server/constants"
+)
+
+// drainServer is a minimal stand-in for the Vela API
+// reporting a worker finishing its running builds.
+type drainServer struct {
+	sync.Mutex
+
+	// number of checks before the running builds finish,
+	// a negative number means the builds never finish
+	finishAfter int
+
+	calls  []string
+	active []bool
+}
+
+func (ds *drainServer) routes(mux *http.ServeMux) {
+	mux.HandleFunc("PUT /api/v1/workers/worker_1", func(w http.ResponseWriter, r *http.Request) {
+		ds.Lock()
+		defer ds.Unlock()
+
+		ds.calls = append(ds.calls, r.Method+" "+r.URL.Path)
+
+		var wkr api.Worker
+
+		_ = json.NewDecoder(r.Body).Decode(&wkr)
+
+		ds.active = append(ds.active, wkr.GetActive())
+
+		_ = json.NewEncoder(w).Encode(wkr)
+	})
+
+	mux.HandleFunc("GET /api/v1/workers/worker_1", func(w http.ResponseWriter, r *http.Request) {
+		ds.Lock()
+		defer ds.Unlock()
+
+		ds.calls = append(ds.calls, r.Method+" "+r.URL.Path)
+
+		wkr := api.Worker{Hostname: new("worker_1"), Active: new(false)}
+
+		if ds.finishAfter != 0 {
+			ds.finishAfter--
+
+			wkr.SetRunningBuilds([]*api.Build{
+				{Repo: &api.Repo{Org: new("github"), Name: new("octocat")}, Number: new(int64(1))},
+				{Repo: &api.Repo{FullName: new("github/hello-world")}, Number: new(int64(2))},
+			})
+		}
+
+		_ = json.NewEncoder(w).Encode(wkr)
+	})
+
+	mux.HandleFunc("DELETE /api/v1/repos/{org}/{repo}/builds/{build}/cancel", func(w http.ResponseWriter, r *http.Request) {
+		ds.Lock()
+		defer ds.Unlock()
+
+		ds.calls = append(ds.calls, r.Method+" "+r.URL.Path)
+
+		_ = json.NewEncoder(w).Encode(api.Build{Status: new(constants.StatusCanceled)})
+	})
+}
+
+func TestWorker_Drain(t *testing.T) {
+	ds := &drainServer{finishAfter: 2}
+
+	s := fakeServer(t, ds.routes)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	// run test
+	got, err := c.Worker.Drain(t.Context(), "worker_1", &WorkerDrainOptions{PollInterval: time.Millisecond})
+	if err != nil {
+		t.Errorf("Drain returned err: %v", err)
+	}
+
+	if got.TimedOut || len(got.Canceled) != 0 || len(got.Worker.GetRunningBuilds()) != 0 {
+		t.Errorf("Drain is %v", got)
+	}
+
+	want := []string{
+		"PUT /api/v1/workers/worker_1",
+		"GET /api/v1/workers/worker_1",
+		"GET /api/v1/workers/worker_1",
+		"GET /api/v1/workers/worker_1",
+	}
+
+	if !reflect.DeepEqual(ds.calls, want) {
+		t.Errorf("Drain calls are %v, want %v", ds.calls, want)
+	}
+
+	if !reflect.DeepEqual(ds.active, []bool{false}) {
+		t.Errorf("Drain should have marked the worker inactive")
+	}
+}
+
+func TestWorker_Drain_Timeout(t *testing.T) {
+	ds := &drainServer{finishAfter: -1}
+
+	s := fakeServer(t, ds.routes)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	// run test
+	got, err := c.Worker.Drain(t.Context(), "worker_1", &WorkerDrainOptions{
+		PollInterval: time.Millisecond,
+		Timeout:      10 * time.Millisecond,
+	})
+	if err == nil {
+		t.Errorf("Drain should have returned err")
+	}
+
+	if !got.TimedOut || len(got.Canceled) != 0 {
+		t.Errorf("Drain is %v", got)
+	}
+}
+
+func TestWorker_Drain_Cancel(t *testing.T) {
+	ds := &drainServer{finishAfter: -1}
+
+	s := fakeServer(t, ds.routes)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	// run test
+	got, err := c.Worker.Drain(t.Context(), "worker_1", &WorkerDrainOptions{
+		PollInterval: time.Millisecond,
+		Timeout:      10 * time.Millisecond,
+		Cancel:       true,
+	})
+	if err != nil {
+		t.Errorf("Drain returned err: %v", err)
+	}
+
+	if !got.TimedOut || len(got.Canceled) != 2 {
+		t.Errorf("Drain is %v", got)
+	}
+
+	want := []string{
+		"DELETE /api/v1/repos/github/octocat/builds/1/cancel",
+		"DELETE /api/v1/repos/github/hello-world/builds/2/cancel",
+	}
+
+	if !reflect.DeepEqual(ds.calls[len(ds.calls)-2:], want) {
+		t.Errorf("Drain calls are %v, want %v", ds.calls, want)
+	}
+}
+
+func TestWorker_Uncordon(t *testing.T) {
+	ds := new(drainServer)
+
+	s := fakeServer(t, ds.routes)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	// run test
+	_, _, err := c.Worker.Uncordon(t.Context(), "worker_1")
+	if err != nil {
+		t.Errorf("Uncordon returned err: %v", err)
+	}
+
+	if !reflect.DeepEqual(ds.active, []bool{true}) {
+		t.Errorf("Uncordon should have marked the worker active")
+	}
+}
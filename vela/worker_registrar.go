@@ -0,0 +1,278 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	api "github.com/go-vela/server/api/types"
+	"github.com/go-vela/server/constants"
+)
+
+// workerRefreshRetry is the minimum time between
+// attempts to rotate the auth token for a worker.
+const workerRefreshRetry = 10 * time.Second
+
+// WorkerRegistrarOptions specifies the parameters
+// used to create a WorkerRegistrar.
+type WorkerRegistrarOptions struct {
+	// Worker to register, the hostname is required.
+	Worker *api.Worker
+
+	// Registration token, or the symmetric token configured
+	// for the server, used to register the worker. When not
+	// provided, a registration token is requested using the
+	// authentication of the client, which must be an admin.
+	Token string
+
+	// Time between check-ins with the server.
+	//
+	// Default: 15m
+	CheckInInterval time.Duration
+
+	// Time before the auth token expires that it is rotated.
+	// The token is rotated on its own schedule, independent
+	// of the check-in interval.
+	//
+	// Default: 5m
+	RefreshBefore time.Duration
+
+	// Function called on every check-in to report the status
+	// and running builds of the worker. When not provided,
+	// the worker is reported as available.
+	Status func() (string, []*api.Build)
+
+	// Function called when a check-in or rotating the auth
+	// token fails. Failed check-ins are retried on the next
+	// check-in and failed rotations are retried after 10s.
+	OnError func(error)
+}
+
+// WorkerRegistrar registers a worker with the server and keeps the
+// registration alive by checking in and rotating the auth token.
+// The client is configured with the auth token for the worker, so
+// it can be used for the rest of the worker's requests.
+type WorkerRegistrar struct {
+	client *Client
+	opt    WorkerRegistrarOptions
+
+	mu    sync.Mutex
+	token string
+	queue *api.QueueInfo
+}
+
+// NewWorkerRegistrar returns a WorkerRegistrar for the worker.
+func NewWorkerRegistrar(c *Client, opt *WorkerRegistrarOptions) (*WorkerRegistrar, error) {
+	if c == nil {
+		return nil, fmt.Errorf("no client provided")
+	}
+
+	if opt == nil || len(opt.Worker.GetHostname()) == 0 {
+		return nil, fmt.Errorf("no worker hostname provided")
+	}
+
+	wr := &WorkerRegistrar{
+		client: c,
+		opt:    *opt,
+	}
+
+	if wr.opt.CheckInInterval <= 0 {
+		wr.opt.CheckInInterval = 15 * time.Minute
+	}
+
+	if wr.opt.RefreshBefore <= 0 {
+		wr.opt.RefreshBefore = 5 * time.Minute
+	}
+
+	return wr, nil
+}
+
+// Register performs the onboarding handshake for the worker. The worker
+// is registered with the registration token, the client is configured
+// with the auth token returned for the worker and the queue details
+// the worker uses to receive builds are returned.
+func (wr *WorkerRegistrar) Register(ctx context.Context) (*api.QueueInfo, error) {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	hostname := wr.opt.Worker.GetHostname()
+
+	token := wr.opt.Token
+	if len(token) == 0 {
+		t, _, err := wr.client.Admin.Worker.RegisterToken(ctx, hostname)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get registration token for worker %s: %w", hostname, err)
+		}
+
+		token = t.GetToken()
+	}
+
+	w := *wr.opt.Worker
+	wr.setStatus(&w)
+
+	wr.client.Authentication.SetTokenAuth(token)
+
+	t, _, err := wr.client.Worker.Add(ctx, &w)
+	if err != nil {
+		return nil, fmt.Errorf("unable to register worker %s: %w", hostname, err)
+	}
+
+	wr.token = t.GetToken()
+	wr.client.Authentication.SetTokenAuth(wr.token)
+
+	q, _, err := wr.client.Queue.GetInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get queue info for worker %s: %w", hostname, err)
+	}
+
+	wr.queue = q
+
+	return q, nil
+}
+
+// Run checks in with the server on every interval until the context
+// is canceled, rotating the auth token before it expires. The worker
+// is registered first if Register was not called. When the context
+// is canceled, the worker checks in a final time before returning.
+func (wr *WorkerRegistrar) Run(ctx context.Context) error {
+	wr.mu.Lock()
+	registered := len(wr.token) > 0
+	wr.mu.Unlock()
+
+	if !registered {
+		_, err := wr.Register(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	ticker := time.NewTicker(wr.opt.CheckInInterval)
+	defer ticker.Stop()
+
+	// the auth token can expire between check-ins,
+	// so it is rotated based on its expiration
+	refresh := wr.refreshTimer(0)
+
+	for {
+		select {
+		case <-ctx.Done():
+			// report the final status of the worker with a
+			// context that outlives the canceled context
+			sctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+
+			wr.report(wr.CheckIn(sctx))
+
+			cancel()
+
+			return nil
+		case <-refresh:
+			wr.report(wr.RefreshAuth(ctx))
+
+			refresh = wr.refreshTimer(workerRefreshRetry)
+		case <-ticker.C:
+			wr.report(wr.CheckIn(ctx))
+		}
+	}
+}
+
+// CheckIn updates the status, running builds and
+// last check-in of the worker with the server.
+func (wr *WorkerRegistrar) CheckIn(ctx context.Context) error {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	hostname := wr.opt.Worker.GetHostname()
+
+	w := &api.Worker{Hostname: new(hostname)}
+	wr.setStatus(w)
+	w.SetLastCheckedIn(time.Now().UTC().Unix())
+
+	_, _, err := wr.client.Worker.Update(ctx, hostname, w)
+	if err != nil {
+		return fmt.Errorf("unable to check in worker %s: %w", hostname, err)
+	}
+
+	return nil
+}
+
+// RefreshAuth rotates the auth token for the worker when it expires
+// within the refresh window. Tokens without an expiration, like the
+// symmetric token for the server, are never rotated.
+func (wr *WorkerRegistrar) RefreshAuth(ctx context.Context) error {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	exp, ok := tokenExpiration(wr.token)
+	if !ok || time.Until(exp) > wr.opt.RefreshBefore {
+		return nil
+	}
+
+	hostname := wr.opt.Worker.GetHostname()
+
+	t, _, err := wr.client.Worker.RefreshAuth(ctx, hostname)
+	if err != nil {
+		return fmt.Errorf("unable to refresh auth token for worker %s: %w", hostname, err)
+	}
+
+	wr.token = t.GetToken()
+	wr.client.Authentication.SetTokenAuth(wr.token)
+
+	return nil
+}
+
+// refreshTimer returns a channel receiving when the auth token
+// enters the refresh window, waiting at least the provided time.
+// Tokens without an expiration return a nil channel.
+func (wr *WorkerRegistrar) refreshTimer(minWait time.Duration) <-chan time.Time {
+	wr.mu.Lock()
+	exp, ok := tokenExpiration(wr.token)
+	wr.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	return time.After(max(time.Until(exp)-wr.opt.RefreshBefore, minWait))
+}
+
+// QueueInfo returns the queue details received
+// when the worker was registered.
+func (wr *WorkerRegistrar) QueueInfo() *api.QueueInfo {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	return wr.queue
+}
+
+// setStatus sets the status and running builds for the worker.
+func (wr *WorkerRegistrar) setStatus(w *api.Worker) {
+	status, builds := constants.WorkerStatusAvailable, []*api.Build{}
+
+	if wr.opt.Status != nil {
+		status, builds = wr.opt.Status()
+	}
+
+	w.SetStatus(status)
+	w.SetRunningBuilds(builds)
+	w.SetLastStatusUpdateAt(time.Now().UTC().Unix())
+}
+
+// report passes the error to the error callback, if any.
+func (wr *WorkerRegistrar) report(err error) {
+	if err == nil {
+		return
+	}
+
+	if wr.opt.OnError != nil {
+		wr.opt.OnError(err)
+
+		return
+	}
+
+	logrus.Debug(err)
+}
@@ -0,0 +1,294 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+
+	api "github.com/go-vela/server/api/types"
+	"github.com/go-vela/server/constants"
+	"github.com/go-vela/server/mock/server"
+)
+
+// registrarServer issues the auth tokens for a checked in worker,
+// leaving the other endpoints to the mock server.
+type registrarServer struct {
+	sync.Mutex
+
+	calls   []string
+	auth    []string
+	token   string
+	workers []api.Worker
+}
+
+func (rs *registrarServer) routes(mux *http.ServeMux) {
+	record := func(r *http.Request) {
+		rs.calls = append(rs.calls, r.Method+" "+r.URL.Path)
+		rs.auth = append(rs.auth, r.Header.Get("Authorization"))
+	}
+
+	mux.HandleFunc("POST /api/v1/workers", func(w http.ResponseWriter, r *http.Request) {
+		rs.Lock()
+		defer rs.Unlock()
+
+		record(r)
+
+		var wkr api.Worker
+
+		_ = json.NewDecoder(r.Body).Decode(&wkr)
+
+		rs.workers = append(rs.workers, wkr)
+
+		_ = json.NewEncoder(w).Encode(api.Token{Token: new(rs.token)})
+	})
+
+	mux.HandleFunc("PUT /api/v1/workers/{worker}", func(w http.ResponseWriter, r *http.Request) {
+		rs.Lock()
+		defer rs.Unlock()
+
+		record(r)
+
+		var wkr api.Worker
+
+		_ = json.NewDecoder(r.Body).Decode(&wkr)
+
+		rs.workers = append(rs.workers, wkr)
+
+		_ = json.NewEncoder(w).Encode(wkr)
+	})
+
+	mux.HandleFunc("POST /api/v1/workers/{worker}/refresh", func(w http.ResponseWriter, r *http.Request) {
+		rs.Lock()
+		defer rs.Unlock()
+
+		record(r)
+
+		_, _ = w.Write([]byte(`{"token":"refreshed-token"}`))
+	})
+}
+
+func TestWorker_NewWorkerRegistrar(t *testing.T) {
+	c, _ := NewClient("http://localhost:8080", "", nil)
+
+	// run test
+	_, err := NewWorkerRegistrar(c, &WorkerRegistrarOptions{Worker: new(api.Worker)})
+	if err == nil {
+		t.Errorf("NewWorkerRegistrar should have returned err")
+	}
+
+	_, err = NewWorkerRegistrar(nil, &WorkerRegistrarOptions{Worker: &api.Worker{Hostname: new("worker_1")}})
+	if err == nil {
+		t.Errorf("NewWorkerRegistrar should have returned err")
+	}
+}
+
+func TestWorker_WorkerRegistrar_Register(t *testing.T) {
+	// setup types
+	var (
+		calls   []string
+		auth    []string
+		workers []api.Worker
+	)
+
+	s := mockServer(t, func(r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		auth = append(auth, r.Header.Get("Authorization"))
+
+		if r.Method == http.MethodPost && r.URL.Path == "/api/v1/workers" {
+			var w api.Worker
+
+			_ = json.NewDecoder(r.Body).Decode(&w)
+
+			workers = append(workers, w)
+		}
+	})
+
+	var registerToken, authToken api.Token
+
+	_ = json.Unmarshal([]byte(server.RegisterTokenResp), &registerToken)
+	_ = json.Unmarshal([]byte(server.AddWorkerResp), &authToken)
+
+	c, _ := NewClient(s.URL, "", nil)
+	c.Authentication.SetTokenAuth("admin-token")
+
+	wr, _ := NewWorkerRegistrar(c, &WorkerRegistrarOptions{
+		Worker: &api.Worker{Hostname: new("worker_1"), Routes: &[]string{"vela"}},
+	})
+
+	// run test
+	q, err := wr.Register(t.Context())
+	if err != nil {
+		t.Fatalf("Register returned err: %v", err)
+	}
+
+	if q.GetQueueAddress() != "redis://redis:6000" || wr.QueueInfo() != q {
+		t.Errorf("Register returned queue info %v", q)
+	}
+
+	wantCalls := []string{
+		"POST /api/v1/admin/workers/worker_1/register",
+		"POST /api/v1/workers",
+		"GET /api/v1/queue/info",
+	}
+
+	if !reflect.DeepEqual(calls, wantCalls) {
+		t.Errorf("Register calls are %v, want %v", calls, wantCalls)
+	}
+
+	wantAuth := []string{"Bearer admin-token", "Bearer " + registerToken.GetToken(), "Bearer " + authToken.GetToken()}
+
+	if !reflect.DeepEqual(auth, wantAuth) {
+		t.Errorf("Register auth is %v, want %v", auth, wantAuth)
+	}
+
+	if w := workers[0]; w.GetStatus() != constants.WorkerStatusAvailable || len(w.GetRoutes()) != 1 {
+		t.Errorf("Register sent worker %v", w)
+	}
+}
+
+func TestWorker_WorkerRegistrar_Run(t *testing.T) {
+	// setup types
+	expiring := makeSampleToken(jwt.MapClaims{"exp": float64(time.Now().Add(time.Minute).Unix())})
+
+	rs := &registrarServer{token: expiring}
+
+	s := fakeServer(t, rs.routes)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	running := []*api.Build{{Number: new(int64(1))}}
+
+	wr, _ := NewWorkerRegistrar(c, &WorkerRegistrarOptions{
+		Worker:          &api.Worker{Hostname: new("worker_1")},
+		Token:           "register-token",
+		CheckInInterval: 10 * time.Millisecond,
+		Status: func() (string, []*api.Build) {
+			return constants.WorkerStatusBusy, running
+		},
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+
+	done := make(chan error)
+
+	// run test
+	go func() { done <- wr.Run(ctx) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for time.Now().Before(deadline) {
+		rs.Lock()
+		n := len(rs.workers)
+		rs.Unlock()
+
+		if n > 2 {
+			break
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+
+	err := <-done
+	if err != nil {
+		t.Errorf("Run returned err: %v", err)
+	}
+
+	rs.Lock()
+	defer rs.Unlock()
+
+	if rs.calls[1] != "POST /api/v1/workers/worker_1/refresh" {
+		t.Errorf("Run should have refreshed the expiring auth token, calls are %v", rs.calls)
+	}
+
+	if rs.auth[len(rs.auth)-1] != "Bearer refreshed-token" {
+		t.Errorf("Run should have used the refreshed auth token")
+	}
+
+	last := rs.workers[len(rs.workers)-1]
+
+	if last.GetStatus() != constants.WorkerStatusBusy || len(last.GetRunningBuilds()) != 1 || last.GetLastCheckedIn() == 0 {
+		t.Errorf("Run checked in with %v", last)
+	}
+}
+
+func TestWorker_WorkerRegistrar_Run_Refresh(t *testing.T) {
+	// setup types
+	expiring := makeSampleToken(jwt.MapClaims{"exp": float64(time.Now().Add(time.Minute).Unix())})
+
+	rs := &registrarServer{token: expiring}
+
+	s := fakeServer(t, rs.routes)
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	// the token expires long before the next check-in
+	wr, _ := NewWorkerRegistrar(c, &WorkerRegistrarOptions{
+		Worker:          &api.Worker{Hostname: new("worker_1")},
+		Token:           "register-token",
+		CheckInInterval: time.Hour,
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+
+	done := make(chan error)
+
+	// run test
+	go func() { done <- wr.Run(ctx) }()
+
+	refreshed := func() bool {
+		rs.Lock()
+		defer rs.Unlock()
+
+		return slices.Contains(rs.calls, "POST /api/v1/workers/worker_1/refresh")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for !refreshed() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+
+	err := <-done
+	if err != nil {
+		t.Errorf("Run returned err: %v", err)
+	}
+
+	if !refreshed() {
+		t.Errorf("Run should have refreshed the auth token before the next check-in")
+	}
+}
+
+func TestWorker_WorkerRegistrar_Run_Error(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":"unauthorized"}`))
+	}))
+	defer s.Close()
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	wr, _ := NewWorkerRegistrar(c, &WorkerRegistrarOptions{
+		Worker: &api.Worker{Hostname: new("worker_1")},
+		Token:  "register-token",
+	})
+
+	// run test
+	err := wr.Run(t.Context())
+	if err == nil {
+		t.Errorf("Run should have returned err")
+	}
+}
@@ -0,0 +1,405 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	api "github.com/go-vela/server/api/types"
+	"github.com/go-vela/server/constants"
+)
+
+// FleetReportOptions specifies the optional parameters
+// to the Worker.Report method.
+type FleetReportOptions struct {
+	// Time since the last check-in after which
+	// a worker is considered stale.
+	//
+	// Default: 30m
+	StaleAfter time.Duration
+
+	// Time a build waits in the queue after which the
+	// route it was queued on is considered starved when
+	// the route has no capacity.
+	//
+	// Default: 10m
+	StarvedAfter time.Duration
+
+	// Function called to look up the version of each worker,
+	// for example from the version endpoint of the worker.
+	// The Vela API does not report the version of workers,
+	// so they are grouped as unknown when not provided.
+	Version func(ctx context.Context, w *api.Worker) (string, error)
+}
+
+// FleetWorker represents the health of a single worker.
+type FleetWorker struct {
+	Hostname      string    `json:"hostname"`
+	Address       string    `json:"address"`
+	Routes        []string  `json:"routes"`
+	Active        bool      `json:"active"`
+	Status        string    `json:"status"`
+	Version       string    `json:"version"`
+	RunningBuilds []string  `json:"running_builds"`
+	BuildLimit    int32     `json:"build_limit"`
+	LastCheckedIn time.Time `json:"last_checked_in"`
+	Stale         bool      `json:"stale"`
+}
+
+// healthy returns whether the worker can receive builds.
+func (w *FleetWorker) healthy() bool {
+	return w.Active && !w.Stale && w.Status != constants.WorkerStatusError
+}
+
+// capacity returns the number of builds the worker can still receive.
+func (w *FleetWorker) capacity() int {
+	if !w.healthy() {
+		return 0
+	}
+
+	return max(int(w.BuildLimit)-len(w.RunningBuilds), 0)
+}
+
+// FleetGroup represents the workers sharing a route, status or version.
+type FleetGroup struct {
+	Name     string `json:"name"`
+	Workers  int    `json:"workers"`
+	Healthy  int    `json:"healthy"`
+	Stale    int    `json:"stale"`
+	Running  int    `json:"running"`
+	Capacity int    `json:"capacity"`
+}
+
+// FleetStarvation represents a route unable to
+// receive the builds waiting in the queue.
+type FleetStarvation struct {
+	Route  string `json:"route"`
+	Reason string `json:"reason"`
+}
+
+// FleetReport represents the inventory and health of the workers.
+type FleetReport struct {
+	GeneratedAt time.Time `json:"generated_at"`
+
+	Workers   []*FleetWorker `json:"workers"`
+	ByRoute   []*FleetGroup  `json:"by_route"`
+	ByStatus  []*FleetGroup  `json:"by_status"`
+	ByVersion []*FleetGroup  `json:"by_version"`
+
+	// Builds in the queue by status and the time the oldest
+	// pending build has waited, encoded in JSON as a duration
+	// string like "20m0s".
+	Pending       int           `json:"pending"`
+	Running       int           `json:"running"`
+	OldestPending time.Duration `json:"oldest_pending"`
+
+	// Routes without capacity with builds that have waited
+	// in the queue for longer than the starved threshold.
+	Starved []*FleetStarvation `json:"starved"`
+
+	// Builds running in the queue that no
+	// worker reports as running.
+	Orphaned []string `json:"orphaned"`
+}
+
+// Report returns the inventory and health of the workers, grouped by
+// route, status and version. The queue is correlated with the builds
+// reported by the workers to find routes unable to receive builds.
+// The queue does not include the route for builds, so each pending
+// build waiting longer than the starved threshold is looked up to
+// find the route it was queued on, sharing the routes cached by the
+// client with Queue.Stats. Builds that fail to be looked up are
+// reported on the unknown route.
+func (svc *WorkerService) Report(ctx context.Context, opt *FleetReportOptions) (*FleetReport, error) {
+	if opt == nil {
+		opt = new(FleetReportOptions)
+	}
+
+	staleAfter := opt.StaleAfter
+	if staleAfter <= 0 {
+		staleAfter = 30 * time.Minute
+	}
+
+	starvedAfter := opt.StarvedAfter
+	if starvedAfter <= 0 {
+		starvedAfter = 10 * time.Minute
+	}
+
+	workers, _, err := svc.GetAll(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list workers: %w", err)
+	}
+
+	// the queue is not paginated by the server,
+	// so every build is returned in one request
+	queue, _, err := svc.client.Admin.Build.GetQueue(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list queue: %w", err)
+	}
+
+	now := time.Now().UTC()
+	r := &FleetReport{GeneratedAt: now}
+
+	reported := make(map[string]bool)
+
+	for i := range *workers {
+		w := &(*workers)[i]
+
+		fw := &FleetWorker{
+			Hostname:      w.GetHostname(),
+			Address:       w.GetAddress(),
+			Routes:        w.GetRoutes(),
+			Active:        w.GetActive(),
+			Status:        w.GetStatus(),
+			Version:       "unknown",
+			RunningBuilds: []string{},
+			BuildLimit:    w.GetBuildLimit(),
+			LastCheckedIn: time.Unix(w.GetLastCheckedIn(), 0).UTC(),
+		}
+
+		// workers without routes receive builds from the default route
+		if len(fw.Routes) == 0 {
+			fw.Routes = []string{constants.DefaultRoute}
+		}
+
+		fw.Stale = now.Sub(fw.LastCheckedIn) > staleAfter
+
+		if opt.Version != nil {
+			v, err := opt.Version(ctx, w)
+			if err == nil && len(v) > 0 {
+				fw.Version = v
+			}
+		}
+
+		for _, b := range w.GetRunningBuilds() {
+			name := fmt.Sprintf("%s#%d", b.GetRepo().GetFullName(), b.GetNumber())
+
+			fw.RunningBuilds = append(fw.RunningBuilds, name)
+			reported[name] = true
+		}
+
+		r.Workers = append(r.Workers, fw)
+	}
+
+	// only the routes for builds waiting longer
+	// than the starved threshold are looked up
+	starved := func(b *api.QueueBuild) bool {
+		return b.GetStatus() == constants.StatusPending && now.Sub(time.Unix(b.GetCreated(), 0)) >= starvedAfter
+	}
+
+	// routes with builds waiting longer than the starved threshold
+	waiting := make(map[string]bool)
+
+	for _, route := range svc.client.Queue.routes(ctx, *queue, starved) {
+		waiting[route] = true
+	}
+
+	for _, b := range *queue {
+		switch b.GetStatus() {
+		case constants.StatusPending:
+			r.Pending++
+			r.OldestPending = max(r.OldestPending, now.Sub(time.Unix(b.GetCreated(), 0)))
+		case constants.StatusRunning:
+			r.Running++
+
+			name := fmt.Sprintf("%s#%d", b.GetFullName(), b.GetNumber())
+			if !reported[name] {
+				r.Orphaned = append(r.Orphaned, name)
+			}
+		}
+	}
+
+	r.ByRoute = fleetGroups(r.Workers, func(w *FleetWorker) []string { return w.Routes })
+	r.ByStatus = fleetGroups(r.Workers, func(w *FleetWorker) []string { return []string{w.Status} })
+	r.ByVersion = fleetGroups(r.Workers, func(w *FleetWorker) []string { return []string{w.Version} })
+
+	r.Starved = fleetStarvation(r.ByRoute, waiting)
+
+	return r, nil
+}
+
+// fleetReportJSON is the JSON encoding of a FleetReport.
+type fleetReportJSON FleetReport
+
+// MarshalJSON encodes the report with the
+// oldest pending time as a duration string.
+func (r FleetReport) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		*fleetReportJSON
+		OldestPending string `json:"oldest_pending"`
+	}{
+		fleetReportJSON: (*fleetReportJSON)(&r),
+		OldestPending:   r.OldestPending.Round(time.Second).String(),
+	})
+}
+
+// UnmarshalJSON decodes the report with the
+// oldest pending time as a duration string.
+func (r *FleetReport) UnmarshalJSON(data []byte) error {
+	v := &struct {
+		*fleetReportJSON
+		OldestPending string `json:"oldest_pending"`
+	}{
+		fleetReportJSON: (*fleetReportJSON)(r),
+	}
+
+	err := json.Unmarshal(data, v)
+	if err != nil {
+		return err
+	}
+
+	if len(v.OldestPending) == 0 {
+		return nil
+	}
+
+	r.OldestPending, err = time.ParseDuration(v.OldestPending)
+
+	return err
+}
+
+// WriteJSON writes the report to w as indented JSON.
+func (r *FleetReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(r)
+}
+
+// WriteTable writes the report to w as plain text tables.
+func (r *FleetReport) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "HOSTNAME\tROUTES\tACTIVE\tSTATUS\tVERSION\tBUILDS\tLAST CHECKED IN\tSTALE")
+
+	for _, fw := range r.Workers {
+		fmt.Fprintf(tw, "%s\t%s\t%t\t%s\t%s\t%d/%d\t%s\t%t\n",
+			fw.Hostname,
+			strings.Join(fw.Routes, ","),
+			fw.Active,
+			fw.Status,
+			fw.Version,
+			len(fw.RunningBuilds),
+			fw.BuildLimit,
+			fw.LastCheckedIn.Format(time.RFC3339),
+			fw.Stale,
+		)
+	}
+
+	for _, section := range []struct {
+		title  string
+		groups []*FleetGroup
+	}{
+		{title: "ROUTE", groups: r.ByRoute},
+		{title: "STATUS", groups: r.ByStatus},
+		{title: "VERSION", groups: r.ByVersion},
+	} {
+		fmt.Fprintf(tw, "\n%s\tWORKERS\tHEALTHY\tSTALE\tRUNNING\tCAPACITY\n", section.title)
+
+		for _, g := range section.groups {
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\n", g.Name, g.Workers, g.Healthy, g.Stale, g.Running, g.Capacity)
+		}
+	}
+
+	fmt.Fprintf(tw, "\nQUEUE\tPENDING\tRUNNING\tOLDEST PENDING\n")
+	fmt.Fprintf(tw, "\t%d\t%d\t%s\n", r.Pending, r.Running, r.OldestPending.Round(time.Second))
+
+	if len(r.Starved) > 0 {
+		fmt.Fprintf(tw, "\nSTARVED ROUTE\tREASON\n")
+
+		for _, s := range r.Starved {
+			fmt.Fprintf(tw, "%s\t%s\n", s.Route, s.Reason)
+		}
+	}
+
+	if len(r.Orphaned) > 0 {
+		fmt.Fprintf(tw, "\nORPHANED BUILD\n")
+
+		for _, o := range r.Orphaned {
+			fmt.Fprintf(tw, "%s\n", o)
+		}
+	}
+
+	return tw.Flush()
+}
+
+// fleetStarvation returns the routes with builds waiting
+// too long that no worker serving the route can receive.
+func fleetStarvation(groups []*FleetGroup, waiting map[string]bool) []*FleetStarvation {
+	routes := make(map[string]*FleetGroup)
+
+	for _, g := range groups {
+		routes[g.Name] = g
+	}
+
+	var starved []*FleetStarvation
+
+	for _, route := range slices.Sorted(maps.Keys(waiting)) {
+		var reason string
+
+		g, ok := routes[route]
+
+		switch {
+		case route == unknownRoute:
+			reason = "route unknown"
+		case !ok:
+			reason = "no workers"
+		case g.Capacity > 0:
+			continue
+		case g.Healthy == 0:
+			reason = "no healthy workers"
+		default:
+			reason = "all workers at capacity"
+		}
+
+		starved = append(starved, &FleetStarvation{Route: route, Reason: reason})
+	}
+
+	return starved
+}
+
+// fleetGroups groups the workers by the keys returned for each worker.
+func fleetGroups(workers []*FleetWorker, keys func(w *FleetWorker) []string) []*FleetGroup {
+	groups := make(map[string]*FleetGroup)
+
+	for _, w := range workers {
+		for _, k := range keys(w) {
+			g, ok := groups[k]
+			if !ok {
+				g = &FleetGroup{Name: k}
+				groups[k] = g
+			}
+
+			g.Workers++
+			g.Running += len(w.RunningBuilds)
+			g.Capacity += w.capacity()
+
+			if w.healthy() {
+				g.Healthy++
+			}
+
+			if w.Stale {
+				g.Stale++
+			}
+		}
+	}
+
+	out := make([]*FleetGroup, 0, len(groups))
+
+	for _, g := range groups {
+		out = append(out, g)
+	}
+
+	slices.SortFunc(out, func(a, b *FleetGroup) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return out
+}
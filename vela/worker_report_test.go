@@ -0,0 +1,232 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	api "github.com/go-vela/server/api/types"
+	"github.com/go-vela/server/constants"
+)
+
+func TestWorker_Report(t *testing.T) {
+	// setup types
+	now := time.Now()
+
+	repo := &api.Repo{FullName: new("github/octocat")}
+
+	workers := []api.Worker{
+		{
+			Hostname:      new("worker_1"),
+			Routes:        &[]string{"small"},
+			Active:        new(true),
+			Status:        new(constants.WorkerStatusBusy),
+			BuildLimit:    new(int32(1)),
+			LastCheckedIn: new(now.Unix()),
+			RunningBuilds: &[]*api.Build{{Repo: repo, Number: new(int64(1))}},
+		},
+		{
+			Hostname:      new("worker_2"),
+			Routes:        &[]string{"gpu"},
+			Active:        new(true),
+			Status:        new(constants.WorkerStatusIdle),
+			BuildLimit:    new(int32(2)),
+			LastCheckedIn: new(now.Add(-time.Hour).Unix()),
+		},
+		{
+			Hostname:      new("worker_3"),
+			Routes:        &[]string{"vela", "large"},
+			Active:        new(true),
+			Status:        new(constants.WorkerStatusAvailable),
+			BuildLimit:    new(int32(2)),
+			LastCheckedIn: new(now.Unix()),
+		},
+	}
+
+	queue := []api.QueueBuild{
+		{Status: new(constants.StatusRunning), Number: new(int32(1)), FullName: new("github/octocat")},
+		{Status: new(constants.StatusRunning), Number: new(int32(2)), FullName: new("github/octocat")},
+		{Status: new(constants.StatusPending), Number: new(int32(3)), FullName: new("github/octocat"), Created: new(now.Add(-20 * time.Minute).Unix())},
+		{Status: new(constants.StatusPending), Number: new(int32(4)), FullName: new("github/octocat"), Created: new(now.Add(-15 * time.Minute).Unix())},
+		{Status: new(constants.StatusPending), Number: new(int32(5)), FullName: new("github/octocat"), Created: new(now.Add(-15 * time.Minute).Unix())},
+		{Status: new(constants.StatusPending), Number: new(int32(6)), FullName: new("github/octocat"), Created: new(now.Unix())},
+		{Status: new(constants.StatusPending), Number: new(int32(7)), FullName: new("github/octocat"), Created: new(now.Add(-15 * time.Minute).Unix())},
+	}
+
+	// routes for the pending builds, the route without capacity
+	// for the recent build is not starved and the lookup for the
+	// build without a route fails
+	routes := map[string]string{"3": "gpu", "4": "large", "5": "arm", "6": "small"}
+
+	var (
+		mu      sync.Mutex
+		lookups []string
+	)
+
+	s := fakeServer(t, func(mux *http.ServeMux) {
+		mux.HandleFunc("GET /api/v1/workers", func(w http.ResponseWriter, _ *http.Request) {
+			_ = json.NewEncoder(w).Encode(workers)
+		})
+
+		// the server ignores the paging parameters for the queue
+		mux.HandleFunc("GET /api/v1/admin/builds/queue", func(w http.ResponseWriter, _ *http.Request) {
+			_ = json.NewEncoder(w).Encode(queue)
+		})
+
+		mux.HandleFunc("GET /api/v1/repos/github/octocat/builds/{build}", func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			lookups = append(lookups, r.PathValue("build"))
+
+			route, ok := routes[r.PathValue("build")]
+			if !ok {
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte(`{"error":"boom"}`))
+
+				return
+			}
+
+			_ = json.NewEncoder(w).Encode(api.Build{Route: new(route)})
+		})
+	})
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	version := func(_ context.Context, w *api.Worker) (string, error) {
+		if w.GetHostname() == "worker_3" {
+			return "v0.28.0", nil
+		}
+
+		return "", nil
+	}
+
+	// run test
+	got, err := c.Worker.Report(t.Context(), &FleetReportOptions{Version: version})
+	if err != nil {
+		t.Fatalf("Report returned err: %v", err)
+	}
+
+	if !got.Workers[1].Stale || got.Workers[0].Stale {
+		t.Errorf("Report should have flagged only worker_2 as stale")
+	}
+
+	wantRoutes := []FleetGroup{
+		{Name: "gpu", Workers: 1, Stale: 1},
+		{Name: "large", Workers: 1, Healthy: 1, Capacity: 2},
+		{Name: "small", Workers: 1, Healthy: 1, Running: 1},
+		{Name: "vela", Workers: 1, Healthy: 1, Capacity: 2},
+	}
+
+	gotRoutes := []FleetGroup{}
+	for _, g := range got.ByRoute {
+		gotRoutes = append(gotRoutes, *g)
+	}
+
+	if !reflect.DeepEqual(gotRoutes, wantRoutes) {
+		t.Errorf("Report routes are %v, want %v", gotRoutes, wantRoutes)
+	}
+
+	if len(got.ByVersion) != 2 || got.ByVersion[0].Name != "unknown" || got.ByVersion[1].Name != "v0.28.0" {
+		t.Errorf("Report versions are %v", got.ByVersion)
+	}
+
+	if got.Pending != 5 || got.Running != 2 || got.OldestPending < 20*time.Minute {
+		t.Errorf("Report queue is %d pending, %d running, %v oldest", got.Pending, got.Running, got.OldestPending)
+	}
+
+	wantStarved := []FleetStarvation{
+		{Route: "arm", Reason: "no workers"},
+		{Route: "gpu", Reason: "no healthy workers"},
+		{Route: "unknown", Reason: "route unknown"},
+	}
+
+	gotStarved := []FleetStarvation{}
+	for _, st := range got.Starved {
+		gotStarved = append(gotStarved, *st)
+	}
+
+	if !reflect.DeepEqual(gotStarved, wantStarved) {
+		t.Errorf("Report starved is %v, want %v", gotStarved, wantStarved)
+	}
+
+	// only the builds waiting longer than the starved threshold are looked up
+	slices.Sort(lookups)
+
+	if !reflect.DeepEqual(lookups, []string{"3", "4", "5", "7"}) {
+		t.Errorf("Report looked up builds %v", lookups)
+	}
+
+	lookups = nil
+
+	// the routes are cached, except for the failed lookup
+	_, err = c.Worker.Report(t.Context(), nil)
+	if err != nil {
+		t.Fatalf("Report returned err: %v", err)
+	}
+
+	if !reflect.DeepEqual(lookups, []string{"7"}) {
+		t.Errorf("Report looked up builds %v again", lookups)
+	}
+
+	if !reflect.DeepEqual(got.Orphaned, []string{"github/octocat#2"}) {
+		t.Errorf("Report orphaned is %v", got.Orphaned)
+	}
+
+	table := new(bytes.Buffer)
+
+	err = got.WriteTable(table)
+	if err != nil {
+		t.Errorf("WriteTable returned err: %v", err)
+	}
+
+	for _, want := range []string{"worker_3", "STARVED ROUTE", "github/octocat#2"} {
+		if !strings.Contains(table.String(), want) {
+			t.Errorf("WriteTable is missing %q:\n%s", want, table.String())
+		}
+	}
+
+	out := new(bytes.Buffer)
+
+	err = got.WriteJSON(out)
+	if err != nil {
+		t.Errorf("WriteJSON returned err: %v", err)
+	}
+
+	var decoded FleetReport
+
+	if !strings.Contains(out.String(), `"oldest_pending": "20m`) {
+		t.Errorf("WriteJSON should have written the oldest pending time as a duration:\n%s", out.String())
+	}
+
+	err = json.Unmarshal(out.Bytes(), &decoded)
+	if err != nil || len(decoded.Workers) != 3 || decoded.OldestPending.Round(time.Minute) != 20*time.Minute {
+		t.Errorf("WriteJSON wrote invalid report: %v", err)
+	}
+}
+
+func TestWorker_Report_Error(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"error":"boom"}`))
+	}))
+	defer s.Close()
+
+	c, _ := NewClient(s.URL, "", nil)
+
+	// run test
+	_, err := c.Worker.Report(t.Context(), nil)
+	if err == nil {
+		t.Errorf("Report should have returned err")
+	}
+}
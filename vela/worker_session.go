@@ -0,0 +1,506 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	api "github.com/go-vela/server/api/types"
+	"github.com/go-vela/server/constants"
+)
+
+// WorkerSessionOptions specifies the parameters
+// used to create a WorkerSession.
+type WorkerSessionOptions struct {
+	// Build token used to authenticate requests for the build.
+	BuildToken string
+
+	// SCM token, and its expiration, for the build.
+	SCMToken    string
+	SCMTokenExp int64
+
+	// Details about the worker recorded on the
+	// build, steps and services it executes.
+	Hostname     string
+	Runtime      string
+	Distribution string
+
+	// Secret values masked in the logs before upload.
+	Secrets []string
+}
+
+// WorkerSession manages the lifecycle of a single build executed
+// by a worker. The session configures the client with build token
+// authentication, keeps track of every step and service it reports
+// on and is safe for concurrent use.
+type WorkerSession struct {
+	client *Client
+	opt    WorkerSessionOptions
+	masker *SecretMasker
+
+	org   string
+	repo  string
+	build int64
+
+	mu         sync.Mutex
+	executable *api.BuildExecutable
+	b          *api.Build
+	finalized  bool
+
+	steps    map[int32]*sessionResource[api.Step]
+	services map[int32]*sessionResource[api.Service]
+
+	stepLogs    map[int32]*sessionResource[api.Log]
+	serviceLogs map[int32]*sessionResource[api.Log]
+}
+
+// sessionResource tracks the latest state of a resource
+// and whether that state was sent to the server.
+type sessionResource[T any] struct {
+	v      *T
+	synced bool
+}
+
+// NewWorkerSession configures the client with build token authentication
+// and returns a session for executing the provided build. The client
+// should be dedicated to the build since its authentication is replaced.
+func NewWorkerSession(c *Client, org, repo string, build int64, opt *WorkerSessionOptions) (*WorkerSession, error) {
+	if c == nil {
+		return nil, fmt.Errorf("no client provided")
+	}
+
+	if opt == nil || len(opt.BuildToken) == 0 {
+		return nil, fmt.Errorf("no build token provided")
+	}
+
+	c.Authentication.SetBuildTokenAuth(opt.BuildToken, opt.SCMToken, opt.SCMTokenExp, fmt.Sprintf("%s/%s", org, repo), build)
+
+	return &WorkerSession{
+		client:      c,
+		opt:         *opt,
+		masker:      NewSecretMasker(opt.Secrets...),
+		org:         org,
+		repo:        repo,
+		build:       build,
+		steps:       make(map[int32]*sessionResource[api.Step]),
+		services:    make(map[int32]*sessionResource[api.Service]),
+		stepLogs:    make(map[int32]*sessionResource[api.Log]),
+		serviceLogs: make(map[int32]*sessionResource[api.Log]),
+	}, nil
+}
+
+// Claim retrieves the executable for the build. The server only
+// provides the executable once, so it is cached for later calls.
+func (ws *WorkerSession) Claim(ctx context.Context) (*api.BuildExecutable, error) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if ws.executable != nil {
+		return ws.executable, nil
+	}
+
+	e, _, err := ws.client.Build.GetBuildExecutable(ctx, ws.org, ws.repo, ws.build)
+	if err != nil {
+		return nil, fmt.Errorf("unable to claim executable for build %s/%s/%d: %w", ws.org, ws.repo, ws.build, err)
+	}
+
+	ws.executable = e
+
+	return e, nil
+}
+
+// Start marks the build as running on the worker.
+func (ws *WorkerSession) Start(ctx context.Context) (*api.Build, error) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	b, err := ws.getBuild(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	b.SetStatus(constants.StatusRunning)
+	b.SetStarted(time.Now().UTC().Unix())
+	ws.setBuildHost(b)
+
+	return ws.updateBuild(ctx, b)
+}
+
+// StartStep marks the step as running on the worker.
+func (ws *WorkerSession) StartStep(ctx context.Context, s *api.Step) (*api.Step, error) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	s.SetStatus(constants.StatusRunning)
+	s.SetStarted(time.Now().UTC().Unix())
+	s.SetHost(ws.opt.Hostname)
+	s.SetRuntime(ws.opt.Runtime)
+	s.SetDistribution(ws.opt.Distribution)
+
+	return ws.syncStep(ctx, s)
+}
+
+// FinishStep marks the step as finished with the exit code. The
+// step fails for a non-zero exit code and errors if err is provided.
+func (ws *WorkerSession) FinishStep(ctx context.Context, s *api.Step, exitCode int32, err error) (*api.Step, error) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	s.SetExitCode(exitCode)
+	s.SetFinished(time.Now().UTC().Unix())
+	s.SetStatus(finishedStatus(exitCode, err))
+
+	if err != nil {
+		s.SetError(err.Error())
+	}
+
+	return ws.syncStep(ctx, s)
+}
+
+// StartService marks the service as running on the worker.
+func (ws *WorkerSession) StartService(ctx context.Context, s *api.Service) (*api.Service, error) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	s.SetStatus(constants.StatusRunning)
+	s.SetStarted(time.Now().UTC().Unix())
+	s.SetHost(ws.opt.Hostname)
+	s.SetRuntime(ws.opt.Runtime)
+	s.SetDistribution(ws.opt.Distribution)
+
+	return ws.syncService(ctx, s)
+}
+
+// FinishService marks the service as finished with the exit code. The
+// service fails for a non-zero exit code and errors if err is provided.
+func (ws *WorkerSession) FinishService(ctx context.Context, s *api.Service, exitCode int32, err error) (*api.Service, error) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	s.SetExitCode(exitCode)
+	s.SetFinished(time.Now().UTC().Unix())
+	s.SetStatus(finishedStatus(exitCode, err))
+
+	if err != nil {
+		s.SetError(err.Error())
+	}
+
+	return ws.syncService(ctx, s)
+}
+
+// AppendStepLog appends data to the log for the step. The
+// log is sent to the server on the next call to FlushLogs.
+func (ws *WorkerSession) AppendStepLog(step int32, data []byte) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	appendSessionLog(ws.stepLogs, step, data)
+}
+
+// AppendServiceLog appends data to the log for the service. The
+// log is sent to the server on the next call to FlushLogs.
+func (ws *WorkerSession) AppendServiceLog(service int32, data []byte) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	appendSessionLog(ws.serviceLogs, service, data)
+}
+
+// FlushLogs sends every log with data that
+// has not yet been sent to the server.
+func (ws *WorkerSession) FlushLogs(ctx context.Context) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	return ws.flushLogs(ctx)
+}
+
+// RefreshInstallToken refreshes the SCM install token for the build.
+// Requests made by the session refresh the token automatically before
+// it expires, so this is only needed to force a refresh.
+func (ws *WorkerSession) RefreshInstallToken(ctx context.Context) (string, error) {
+	_, err := ws.client.Authentication.RefreshInstallToken(ctx, ws.org, ws.repo, ws.build)
+	if err != nil {
+		return "", fmt.Errorf("unable to refresh install token for build %s/%s/%d: %w", ws.org, ws.repo, ws.build, err)
+	}
+
+	return ws.client.Authentication.SCMToken(), nil
+}
+
+// Finalize flushes the logs, finishes any step or service still
+// running and marks the build as finished. When status is empty,
+// it is derived from the steps reported by the session. Changes
+// that fail to be sent are retried by calling Finalize again, and
+// calls after a successful Finalize return the finished build.
+func (ws *WorkerSession) Finalize(ctx context.Context, status string, buildErr error) (*api.Build, error) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if ws.finalized {
+		return ws.b, nil
+	}
+
+	if len(status) == 0 {
+		status = ws.derivedStatus(buildErr)
+	}
+
+	// resources still running when the build finishes are
+	// canceled along with the build or killed otherwise
+	leftover := constants.StatusKilled
+	if status == constants.StatusCanceled {
+		leftover = constants.StatusCanceled
+	}
+
+	now := time.Now().UTC().Unix()
+
+	var errs []error
+
+	if err := ws.flushLogs(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	for _, n := range sortedKeys(ws.steps) {
+		r := ws.steps[n]
+
+		if r.v.GetFinished() == 0 {
+			r.v.SetStatus(leftover)
+			r.v.SetFinished(now)
+			r.synced = false
+		}
+
+		if !r.synced {
+			if _, err := ws.syncStep(ctx, r.v); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	for _, n := range sortedKeys(ws.services) {
+		r := ws.services[n]
+
+		if r.v.GetFinished() == 0 {
+			r.v.SetStatus(leftover)
+			r.v.SetFinished(now)
+			r.synced = false
+		}
+
+		if !r.synced {
+			if _, err := ws.syncService(ctx, r.v); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	b, err := ws.getBuild(ctx)
+	if err != nil {
+		return nil, errors.Join(append(errs, err)...)
+	}
+
+	b.SetStatus(status)
+	b.SetFinished(now)
+	ws.setBuildHost(b)
+
+	if b.GetStarted() == 0 {
+		b.SetStarted(now)
+	}
+
+	if buildErr != nil {
+		b.SetError(buildErr.Error())
+	}
+
+	b, err = ws.updateBuild(ctx, b)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return b, errors.Join(errs...)
+	}
+
+	ws.finalized = true
+
+	return b, nil
+}
+
+// getBuild returns the build, fetching it from the server if needed.
+func (ws *WorkerSession) getBuild(ctx context.Context) (*api.Build, error) {
+	if ws.b != nil {
+		return ws.b, nil
+	}
+
+	b, _, err := ws.client.Build.Get(ctx, ws.org, ws.repo, ws.build)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get build %s/%s/%d: %w", ws.org, ws.repo, ws.build, err)
+	}
+
+	// ensure the build can be updated even if
+	// the server omitted the repo details
+	if len(b.GetRepo().GetOrg()) == 0 {
+		b.SetRepo(&api.Repo{Org: new(ws.org), Name: new(ws.repo)})
+	}
+
+	if b.GetNumber() == 0 {
+		b.SetNumber(ws.build)
+	}
+
+	ws.b = b
+
+	return b, nil
+}
+
+// updateBuild sends the build to the server, keeping the local
+// state so a failed update is retried on the next change.
+func (ws *WorkerSession) updateBuild(ctx context.Context, b *api.Build) (*api.Build, error) {
+	_, _, err := ws.client.Build.Update(ctx, b)
+	if err != nil {
+		return b, fmt.Errorf("unable to update build %s/%s/%d: %w", ws.org, ws.repo, ws.build, err)
+	}
+
+	return b, nil
+}
+
+// setBuildHost records the details about the worker on the build.
+func (ws *WorkerSession) setBuildHost(b *api.Build) {
+	b.SetHost(ws.opt.Hostname)
+	b.SetRuntime(ws.opt.Runtime)
+	b.SetDistribution(ws.opt.Distribution)
+}
+
+// syncStep tracks the step and sends it to the server.
+func (ws *WorkerSession) syncStep(ctx context.Context, s *api.Step) (*api.Step, error) {
+	r := &sessionResource[api.Step]{v: s}
+	ws.steps[s.GetNumber()] = r
+
+	_, _, err := ws.client.Step.Update(ctx, ws.org, ws.repo, ws.build, s)
+	if err != nil {
+		return s, fmt.Errorf("unable to update step %d for build %s/%s/%d: %w", s.GetNumber(), ws.org, ws.repo, ws.build, err)
+	}
+
+	r.synced = true
+
+	return s, nil
+}
+
+// syncService tracks the service and sends it to the server.
+func (ws *WorkerSession) syncService(ctx context.Context, s *api.Service) (*api.Service, error) {
+	r := &sessionResource[api.Service]{v: s}
+	ws.services[s.GetNumber()] = r
+
+	_, _, err := ws.client.Svc.Update(ctx, ws.org, ws.repo, ws.build, s)
+	if err != nil {
+		return s, fmt.Errorf("unable to update service %d for build %s/%s/%d: %w", s.GetNumber(), ws.org, ws.repo, ws.build, err)
+	}
+
+	r.synced = true
+
+	return s, nil
+}
+
+// flushLogs sends every log that has not been synced.
+func (ws *WorkerSession) flushLogs(ctx context.Context) error {
+	var errs []error
+
+	for _, n := range sortedKeys(ws.stepLogs) {
+		r := ws.stepLogs[n]
+		if r.synced {
+			continue
+		}
+
+		_, err := ws.client.Log.UpdateStep(ctx, ws.org, ws.repo, ws.build, n, ws.maskedLog(r.v))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to upload log for step %d: %w", n, err))
+
+			continue
+		}
+
+		r.synced = true
+	}
+
+	for _, n := range sortedKeys(ws.serviceLogs) {
+		r := ws.serviceLogs[n]
+		if r.synced {
+			continue
+		}
+
+		_, err := ws.client.Log.UpdateService(ctx, ws.org, ws.repo, ws.build, n, ws.maskedLog(r.v))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to upload log for service %d: %w", n, err))
+
+			continue
+		}
+
+		r.synced = true
+	}
+
+	return errors.Join(errs...)
+}
+
+// maskedLog returns a copy of the log with the secret values masked.
+// The complete log is masked on every upload, so secret values split
+// across appends are still masked.
+func (ws *WorkerSession) maskedLog(l *api.Log) *api.Log {
+	return &api.Log{Data: new(ws.masker.Mask(l.GetData()))}
+}
+
+// derivedStatus returns the status for the build based on
+// the steps reported by the session.
+func (ws *WorkerSession) derivedStatus(buildErr error) string {
+	if buildErr != nil {
+		return constants.StatusError
+	}
+
+	status := constants.StatusSuccess
+
+	for _, r := range ws.steps {
+		switch r.v.GetStatus() {
+		case constants.StatusError:
+			return constants.StatusError
+		case constants.StatusFailure:
+			status = constants.StatusFailure
+		}
+	}
+
+	return status
+}
+
+// appendSessionLog appends data to the tracked log.
+func appendSessionLog(logs map[int32]*sessionResource[api.Log], n int32, data []byte) {
+	r, ok := logs[n]
+	if !ok {
+		r = &sessionResource[api.Log]{v: new(api.Log)}
+		logs[n] = r
+	}
+
+	r.v.AppendData(data)
+	r.synced = false
+}
+
+// finishedStatus returns the status for a finished step or service.
+func finishedStatus(exitCode int32, err error) string {
+	switch {
+	case err != nil:
+		return constants.StatusError
+	case exitCode != 0:
+		return constants.StatusFailure
+	default:
+		return constants.StatusSuccess
+	}
+}
+
+// sortedKeys returns the keys of the map in ascending order.
+func sortedKeys[T any](m map[int32]T) []int32 {
+	keys := make([]int32, 0, len(m))
+
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
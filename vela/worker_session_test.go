@@ -0,0 +1,272 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vela
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	api "github.com/go-vela/server/api/types"
+	"github.com/go-vela/server/constants"
+)
+
+// sessionServer records the build, step and log updates sent
+// by a worker, leaving the other endpoints to the mock server.
+type sessionServer struct {
+	sync.Mutex
+
+	calls     []string
+	builds    []api.Build
+	steps     map[int32]*api.Step
+	logs      map[string]string
+	failSteps int
+	auth      []string
+}
+
+func (ss *sessionServer) routes(mux *http.ServeMux) {
+	record := func(r *http.Request) {
+		ss.calls = append(ss.calls, r.Method+" "+r.URL.Path)
+		ss.auth = append(ss.auth, r.Header.Get("Authorization"))
+	}
+
+	mux.HandleFunc("PUT /api/v1/repos/github/octocat/builds/1", func(w http.ResponseWriter, r *http.Request) {
+		ss.Lock()
+		defer ss.Unlock()
+
+		record(r)
+
+		var b api.Build
+
+		_ = json.NewDecoder(r.Body).Decode(&b)
+
+		ss.builds = append(ss.builds, b)
+
+		_ = json.NewEncoder(w).Encode(b)
+	})
+
+	mux.HandleFunc("PUT /api/v1/repos/github/octocat/builds/1/steps/{step}", func(w http.ResponseWriter, r *http.Request) {
+		ss.Lock()
+		defer ss.Unlock()
+
+		record(r)
+
+		if ss.failSteps > 0 {
+			ss.failSteps--
+
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"error":"boom"}`))
+
+			return
+		}
+
+		var s api.Step
+
+		_ = json.NewDecoder(r.Body).Decode(&s)
+
+		ss.steps[s.GetNumber()] = &s
+
+		_ = json.NewEncoder(w).Encode(s)
+	})
+
+	mux.HandleFunc("PUT /api/v1/repos/github/octocat/builds/1/{kind}/{number}/logs", func(w http.ResponseWriter, r *http.Request) {
+		ss.Lock()
+		defer ss.Unlock()
+
+		record(r)
+
+		var l api.Log
+
+		_ = json.NewDecoder(r.Body).Decode(&l)
+
+		ss.logs[r.PathValue("kind")+"/"+r.PathValue("number")] = string(l.GetData())
+
+		_, _ = w.Write([]byte(`{}`))
+	})
+}
+
+func testSessionServer() *sessionServer {
+	return &sessionServer{
+		steps: make(map[int32]*api.Step),
+		logs:  make(map[string]string),
+	}
+}
+
+func testWorkerSession(t *testing.T, url string) *WorkerSession {
+	t.Helper()
+
+	c, _ := NewClient(url, "", nil)
+
+	ws, err := NewWorkerSession(c, "github", "octocat", 1, &WorkerSessionOptions{
+		BuildToken:  "build-token",
+		SCMToken:    "scm-token",
+		SCMTokenExp: time.Now().Add(time.Hour).Unix(),
+		Hostname:    "worker_1",
+		Runtime:     "docker",
+		Secrets:     []string{"s3cr3t"},
+	})
+	if err != nil {
+		t.Fatalf("NewWorkerSession returned err: %v", err)
+	}
+
+	return ws
+}
+
+func TestWorkerSession_NewWorkerSession(t *testing.T) {
+	c, _ := NewClient("http://localhost:8080", "", nil)
+
+	// run test
+	_, err := NewWorkerSession(c, "github", "octocat", 1, nil)
+	if err == nil {
+		t.Errorf("NewWorkerSession should have returned err")
+	}
+
+	_, err = NewWorkerSession(nil, "github", "octocat", 1, &WorkerSessionOptions{BuildToken: "foo"})
+	if err == nil {
+		t.Errorf("NewWorkerSession should have returned err")
+	}
+
+	_, err = NewWorkerSession(c, "github", "octocat", 1, &WorkerSessionOptions{BuildToken: "foo"})
+	if err != nil {
+		t.Errorf("NewWorkerSession returned err: %v", err)
+	}
+
+	if !c.Authentication.HasBuildTokenAuth() {
+		t.Errorf("NewWorkerSession should have set build token auth")
+	}
+}
+
+func TestWorkerSession_Lifecycle(t *testing.T) {
+	ss := testSessionServer()
+
+	s := fakeServer(t, ss.routes)
+
+	ws := testWorkerSession(t, s.URL)
+
+	// run test
+	e, err := ws.Claim(t.Context())
+	if err != nil {
+		t.Fatalf("Claim returned err: %v", err)
+	}
+
+	again, _ := ws.Claim(t.Context())
+	if again != e {
+		t.Errorf("Claim should have returned the cached executable")
+	}
+
+	_, err = ws.Start(t.Context())
+	if err != nil {
+		t.Errorf("Start returned err: %v", err)
+	}
+
+	clone := &api.Step{Number: new(int32(1)), Name: new("clone")}
+	test := &api.Step{Number: new(int32(2)), Name: new("test")}
+	db := &api.Service{Number: new(int32(1)), Name: new("postgres")}
+
+	_, _ = ws.StartService(t.Context(), db)
+	_, _ = ws.StartStep(t.Context(), clone)
+
+	ws.AppendStepLog(1, []byte("cloning\n"))
+
+	_, _ = ws.FinishStep(t.Context(), clone, 0, nil)
+	_, _ = ws.StartStep(t.Context(), test)
+
+	ws.AppendStepLog(2, []byte("FAIL s3c"))
+	ws.AppendStepLog(2, []byte("r3t\n"))
+	ws.AppendServiceLog(1, []byte("ready\n"))
+
+	_, err = ws.FinishStep(t.Context(), test, 1, nil)
+	if err != nil {
+		t.Errorf("FinishStep returned err: %v", err)
+	}
+
+	b, err := ws.Finalize(t.Context(), "", nil)
+	if err != nil {
+		t.Fatalf("Finalize returned err: %v", err)
+	}
+
+	if b.GetStatus() != constants.StatusFailure || b.GetFinished() == 0 || b.GetHost() != "worker_1" {
+		t.Errorf("Finalize build is %v", b)
+	}
+
+	if ss.steps[2].GetExitCode() != 1 || ss.steps[2].GetStatus() != constants.StatusFailure {
+		t.Errorf("FinishStep sent %v", ss.steps[2])
+	}
+
+	// the service was never finished by the worker
+	if db.GetStatus() != constants.StatusKilled || db.GetFinished() == 0 {
+		t.Errorf("Finalize should have killed the running service, got %v", db.GetStatus())
+	}
+
+	want := map[string]string{
+		"steps/1":    "cloning\n",
+		"steps/2":    "FAIL ***\n",
+		"services/1": "ready\n",
+	}
+
+	if !reflect.DeepEqual(ss.logs, want) {
+		t.Errorf("Finalize logs are %v, want %v", ss.logs, want)
+	}
+
+	for _, a := range ss.auth {
+		if a != "Bearer build-token" {
+			t.Errorf("WorkerSession sent authorization %q", a)
+		}
+	}
+
+	// finalizing again should not send any requests
+	calls := len(ss.calls)
+
+	_, err = ws.Finalize(t.Context(), constants.StatusSuccess, nil)
+	if err != nil {
+		t.Errorf("Finalize returned err: %v", err)
+	}
+
+	if len(ss.calls) != calls {
+		t.Errorf("Finalize should not have sent requests after finishing")
+	}
+}
+
+func TestWorkerSession_Finalize_Retry(t *testing.T) {
+	ss := testSessionServer()
+
+	s := fakeServer(t, ss.routes)
+
+	ws := testWorkerSession(t, s.URL)
+
+	step := &api.Step{Number: new(int32(1)), Name: new("build")}
+
+	ss.failSteps = 2
+
+	// run test
+	_, err := ws.StartStep(t.Context(), step)
+	if err == nil {
+		t.Errorf("StartStep should have returned err")
+	}
+
+	_, err = ws.Finalize(t.Context(), constants.StatusCanceled, errors.New("canceled by user"))
+	if err == nil {
+		t.Errorf("Finalize should have returned err")
+	}
+
+	if len(ss.builds) != 1 {
+		t.Errorf("Finalize should have updated the build despite failures")
+	}
+
+	b, err := ws.Finalize(t.Context(), constants.StatusCanceled, errors.New("canceled by user"))
+	if err != nil {
+		t.Errorf("Finalize returned err: %v", err)
+	}
+
+	if b.GetStatus() != constants.StatusCanceled || b.GetError() != "canceled by user" {
+		t.Errorf("Finalize build is %v", b)
+	}
+
+	if got := ss.steps[1]; got.GetStatus() != constants.StatusCanceled || got.GetFinished() == 0 {
+		t.Errorf("Finalize should have canceled the step, got %v", got)
+	}
+}